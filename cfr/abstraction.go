@@ -0,0 +1,195 @@
+package cfr
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Abstraction maps the states of a game onto the info sets a Strategy
+// learns, and the actions of those info sets back onto the game's actions.
+// A nil Abstraction on a Strategy uses the game's own info set keys and
+// valid actions.
+type Abstraction interface {
+	// InfoSetKey returns the key of the abstract info set containing state
+	InfoSetKey(state State) InfoSetKey
+	// Actions returns the abstract actions available at state
+	Actions(state State) []Action
+	// ConcreteAction maps an abstract action at state to a valid action
+	ConcreteAction(state State, action Action) Action
+}
+
+// wrapper is implemented by states that play out another state, such as
+// re-solving subgames, so abstractions can see the game underneath. It
+// returns nil while no inner state is being played.
+type wrapper interface {
+	unwrap() State
+}
+
+// infoSetKey returns the key the info set containing state is stored under
+func (strat *Strategy) infoSetKey(state State) PackedKey {
+	if packer, ok := strat.Abstraction.(PackedKeyer); ok {
+		if key, ok := packer.PackedInfoSetKey(state); ok {
+			return key
+		}
+	}
+	if strat.Abstraction == nil {
+		return hashKey(state.GetInfoSetKey())
+	}
+	return hashKey(strat.Abstraction.InfoSetKey(state))
+}
+
+func (strat *Strategy) actions(state State) []Action {
+	if strat.Abstraction == nil {
+		return state.ValidActions()
+	}
+	return strat.Abstraction.Actions(state)
+}
+
+func (strat *Strategy) concreteAction(state State, action Action) Action {
+	if strat.Abstraction == nil {
+		return action
+	}
+	return strat.Abstraction.ConcreteAction(state, action)
+}
+
+// EuchreAbstraction is the abstraction Euchre strategies are trained with.
+// Other games, and states of other games, pass through unchanged.
+type EuchreAbstraction struct {
+	// NormalizeTrump relabels the suits so trump is always spades
+	NormalizeTrump bool
+	// CanonicalSuits swaps the two suits of the other colour from trump
+	// into a canonical order, so mirror images share an info set
+	CanonicalSuits bool
+	// CollapseCards offers one action for each class of equivalent cards:
+	// cards of one effective suit with nothing left in play between them
+	CollapseCards bool
+}
+
+// NewEuchreAbstraction returns the abstraction used for training: trump
+// normalized, suits canonical and cards collapsed. The keys follow the
+// KeyMode of the states.
+func NewEuchreAbstraction() EuchreAbstraction {
+	return EuchreAbstraction{
+		NormalizeTrump: true,
+		CanonicalSuits: true,
+		CollapseCards:  true,
+	}
+}
+
+// underlyingEuchre finds the Euchre state being played under state
+func underlyingEuchre(state State) (*EuchreState, bool) {
+	for {
+		wrapped, ok := state.(wrapper)
+		if !ok {
+			break
+		}
+		if state = wrapped.unwrap(); state == nil {
+			return nil, false
+		}
+	}
+	euchreState, ok := state.(*EuchreState)
+	return euchreState, ok
+}
+
+// view finds the Euchre state under state and relabels its suits as the
+// abstraction sees them
+func (abstraction EuchreAbstraction) view(state State) (*EuchreState, bool) {
+	euchreState, ok := underlyingEuchre(state)
+	if !ok {
+		return nil, false
+	}
+	relabeling := abstraction.relabeling(euchreState)
+	if relabeling.identity() {
+		return euchreState, true
+	}
+	relabeled := euchreState.Clone()
+	relabeling.apply(&relabeled)
+	return &relabeled, true
+}
+
+// InfoSetKey implements Abstraction
+func (abstraction EuchreAbstraction) InfoSetKey(state State) InfoSetKey {
+	euchreState, ok := abstraction.view(state)
+	if !ok {
+		return state.GetInfoSetKey()
+	}
+	return euchreState.GetInfoSetKey()
+}
+
+// Actions implements Abstraction
+func (abstraction EuchreAbstraction) Actions(state State) []Action {
+	euchreState, ok := abstraction.view(state)
+	if !ok {
+		return state.ValidActions()
+	}
+	actions := euchreState.ValidActions()
+	if abstraction.CollapseCards && !euchreState.bidding {
+		actions = collapseCards(euchreState, actions)
+	}
+	return actions
+}
+
+// ConcreteAction implements Abstraction. The action is relabeled back to
+// the state's suits. An action naming a card that can't be played stands
+// for a valid card equivalent to it.
+func (abstraction EuchreAbstraction) ConcreteAction(state State, action Action) Action {
+	euchreState, ok := underlyingEuchre(state)
+	if !ok || action == Action(PASS_BID) {
+		return action
+	}
+	if euchreState.bidding {
+		// Calls are named by their suit alone
+		return Action(abstraction.relabeling(euchreState).concreteCard(Card(action)))
+	}
+
+	card := abstraction.relabeling(euchreState).concreteCard(Card(action))
+	validActions := euchreState.ValidActions()
+	for _, validAction := range validActions {
+		if Card(validAction) == card {
+			return validAction
+		}
+	}
+	for _, validAction := range validActions {
+		if euchreState.equivalentCards(euchreState.currentAgent, Card(validAction), card) {
+			return validAction
+		}
+	}
+	panic(fmt.Sprintf("No valid card is equivalent to the %s", card.ToString()))
+}
+
+// collapseCards splits the valid cards into classes of equivalent cards,
+// as decided by equivalentCards, and keeps the lowest ranked card of each.
+// Cards are compared by effective suit, so the left bower joins trump.
+func collapseCards(state *EuchreState, validActions []Action) []Action {
+	strength := func(card Card) int {
+		suit := card.effectiveSuit(state.TrumpSuit)
+		return int(suit)*24 + getRankTable(state.TrumpSuit, suit).rank(card)
+	}
+
+	cards := make([]Card, len(validActions))
+	for i, action := range validActions {
+		cards[i] = Card(action)
+	}
+	sort.Slice(cards, func(j, k int) bool {
+		return strength(cards[j]) < strength(cards[k])
+	})
+
+	kept := make(map[Card]bool, len(cards))
+	var representative Card
+	for _, card := range cards {
+		if representative != 0 && state.equivalentCards(state.currentAgent, representative, card) {
+			continue
+		}
+		representative = card
+		kept[card] = true
+	}
+
+	// Keep the order of the valid actions
+	collapsed := make([]Action, 0, len(kept))
+	for _, action := range validActions {
+		if kept[Card(action)] {
+			collapsed = append(collapsed, action)
+		}
+	}
+	return collapsed
+}
@@ -0,0 +1,119 @@
+package cfr
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestCollapseCards(t *testing.T) {
+	tests := []struct {
+		name    string
+		hand    []Card
+		actions int
+	}{
+		{"trump ten and queen", []Card{makeCard(SPADES, TEN), makeCard(SPADES, QUEEN)}, 1},
+		{"ace and both bowers", []Card{makeCard(SPADES, ACE), makeCard(CLUBS, JACK), makeCard(SPADES, JACK)}, 1},
+		{"ace and left bower", []Card{makeCard(SPADES, ACE), makeCard(CLUBS, JACK)}, 1},
+		{"ace and right bower", []Card{makeCard(SPADES, ACE), makeCard(SPADES, JACK)}, 2},
+		{"left bower and a club", []Card{makeCard(CLUBS, TEN), makeCard(CLUBS, JACK)}, 2},
+		{"clubs around the left bower", []Card{makeCard(CLUBS, TEN), makeCard(CLUBS, QUEEN)}, 1},
+		{"diamonds around their jack", []Card{makeCard(DIAMONDS, TEN), makeCard(DIAMONDS, QUEEN)}, 2},
+		{"hearts nine to jack", []Card{makeCard(HEARTS, NINE), makeCard(HEARTS, TEN), makeCard(HEARTS, JACK)}, 1},
+		{"different suits", []Card{makeCard(DIAMONDS, ACE), makeCard(HEARTS, NINE)}, 2},
+	}
+
+	abstraction := EuchreAbstraction{CollapseCards: true}
+	for _, test := range tests {
+		state := EuchreState{TrumpSuit: SPADES}
+		state.playerHands[0] = NewCardSet(test.hand...)
+		actions := abstraction.Actions(&state)
+		if len(actions) != test.actions {
+			t.Errorf("%s: got actions %v, expected %d", test.name, actions, test.actions)
+		}
+		for _, action := range actions {
+			if !isValid(&state, action) {
+				t.Errorf("%s: %v isn't a valid card", test.name, action)
+			}
+		}
+	}
+}
+
+func TestEuchreAbstractionKeysFollowKeyMode(t *testing.T) {
+	state := playedInto(rand.New(rand.NewSource(1)), 6)
+	abstraction := NewEuchreAbstraction()
+	normalized := state.Clone()
+	normalized.Normalize(normalized.TrumpSuit)
+
+	if key := abstraction.InfoSetKey(&state); key != normalized.compactKey() {
+		t.Errorf("The default keys should be compact, got %v", key)
+	}
+	state.KeyMode = PERFECT_RECALL_KEYS
+	normalized.KeyMode = PERFECT_RECALL_KEYS
+	if key := abstraction.InfoSetKey(&state); key != normalized.perfectRecallKey() {
+		t.Errorf("Perfect recall states should get perfect recall keys, got %v", key)
+	}
+}
+
+// collapseState deals the hands with spades as trump and seat 0 to lead
+func collapseState(hands [4][]Card) *EuchreState {
+	state := EuchreState{
+		TrumpSuit: SPADES,
+		upcard:    makeCard(SPADES, NINE),
+		handSize:  len(hands[0]),
+	}
+	for seat, hand := range hands {
+		state.playerHands[seat] = NewCardSet(hand...)
+	}
+	return &state
+}
+
+func TestCollapseKeepsCardsSplitByTheTrick(t *testing.T) {
+	state := collapseState([4][]Card{
+		{makeCard(HEARTS, QUEEN), makeCard(CLUBS, NINE)},
+		{makeCard(HEARTS, JACK), makeCard(HEARTS, KING)},
+		{makeCard(HEARTS, NINE), makeCard(CLUBS, TEN)},
+		{makeCard(HEARTS, TEN), makeCard(CLUBS, QUEEN)},
+	})
+	state.TakeAction(Action(makeCard(HEARTS, QUEEN)))
+
+	abstraction := EuchreAbstraction{CollapseCards: true}
+	actions := abstraction.Actions(state)
+	if len(actions) != 2 {
+		t.Fatalf("The queen on the table splits the jack and king, got actions %v", actions)
+	}
+
+	// Once the queen's trick is over nothing splits them
+	state = collapseState([4][]Card{
+		{makeCard(CLUBS, NINE)},
+		{makeCard(HEARTS, JACK), makeCard(HEARTS, KING)},
+		{makeCard(CLUBS, TEN)},
+		{makeCard(CLUBS, QUEEN)},
+	})
+	state.played = NewCardSet(makeCard(HEARTS, QUEEN))
+	if !state.equivalentCards(1, makeCard(HEARTS, JACK), makeCard(HEARTS, KING)) {
+		t.Error("The jack and king should merge once the queen's trick is over")
+	}
+}
+
+func TestCollapseMergesTheBowers(t *testing.T) {
+	rightBower := makeCard(SPADES, JACK)
+	leftBower := makeCard(CLUBS, JACK)
+	state := collapseState([4][]Card{
+		{makeCard(HEARTS, NINE), rightBower, leftBower},
+		{makeCard(HEARTS, TEN), makeCard(HEARTS, QUEEN), makeCard(HEARTS, KING)},
+		{makeCard(DIAMONDS, NINE), makeCard(DIAMONDS, TEN), makeCard(DIAMONDS, QUEEN)},
+		{makeCard(CLUBS, NINE), makeCard(CLUBS, TEN), makeCard(CLUBS, QUEEN)},
+	})
+
+	abstraction := EuchreAbstraction{CollapseCards: true}
+	actions := abstraction.Actions(state)
+	if len(actions) != 2 {
+		t.Fatalf("Expected the bowers to merge, got actions %v", actions)
+	}
+	if actions[0] != Action(leftBower) && actions[1] != Action(leftBower) {
+		t.Errorf("Expected the left bower to stand for both bowers, got actions %v", actions)
+	}
+	if concrete := abstraction.ConcreteAction(state, Action(rightBower)); concrete != Action(rightBower) {
+		t.Errorf("A valid card should map to itself, got %v", concrete)
+	}
+}
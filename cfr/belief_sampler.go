@@ -0,0 +1,166 @@
+package cfr
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// PolicyModel predicts how a seat plays: the probability of each valid
+// action at a state. Strategy, StrategyAgent and RandomAgent are models.
+type PolicyModel interface {
+	Policy(state State) map[Action]float64
+}
+
+// storedPolicyModel is a PolicyModel backed by stored policies. It reports
+// when it has none for a state instead of filling one in uniformly.
+type storedPolicyModel interface {
+	storedPolicy(state State) (map[Action]float64, bool)
+}
+
+// WorldSampler draws complete worlds the current agent of state could not
+// tell apart from the real one
+type WorldSampler interface {
+	SampleWorld(state State) (State, error)
+}
+
+// UniformSampler deals the hidden cards uniformly using the state's own
+// Sampler
+type UniformSampler struct{}
+
+// SampleWorld implements WorldSampler
+func (sampler UniformSampler) SampleWorld(state State) (State, error) {
+	return state.(Sampler).SampleWorld()
+}
+
+// BeliefSampler infers hands from the cards played. It draws NumCandidates
+// worlds uniformly and picks one of them with probability proportional to
+// how likely the other seats were to play the history in that world
+// according to Model. NumCandidates below 1 counts as 1, which samples
+// uniformly. Only Euchre states carry the history needed, others are
+// sampled uniformly. A Model with no policy stored for a state of the
+// history, or one that doesn't sum to 1, is an error rather than a guess.
+type BeliefSampler struct {
+	Model         PolicyModel
+	NumCandidates int
+}
+
+// SampleWorld implements WorldSampler
+func (sampler BeliefSampler) SampleWorld(state State) (State, error) {
+	euchreState, ok := state.(*EuchreState)
+	if !ok {
+		return UniformSampler{}.SampleWorld(state)
+	}
+
+	numCandidates := sampler.NumCandidates
+	if numCandidates < 1 {
+		numCandidates = 1
+	}
+
+	playerID := euchreState.GetCurrentAgent()
+	candidates := make([]EuchreState, 0, numCandidates)
+	weights := make([]float64, 0, numCandidates)
+	total := 0.0
+	var err error
+	for c := 0; c < numCandidates; c++ {
+		var world EuchreState
+		world, err = euchreState.SampleInfoSet()
+		if err != nil {
+			continue
+		}
+		weight, err := historyLikelihood(sampler.Model, &world, playerID)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, world)
+		weights = append(weights, weight)
+		total += weight
+	}
+	if len(candidates) == 0 {
+		return nil, err
+	}
+
+	// No candidate explains the history, so fall back to a uniform one
+	if total == 0 {
+		return &candidates[rand.Intn(len(candidates))], nil
+	}
+
+	num := rand.Float64() * total
+	sum := 0.0
+	for i, weight := range weights {
+		sum += weight
+		if sum > num {
+			return &candidates[i], nil
+		}
+	}
+	return &candidates[len(candidates)-1], nil
+}
+
+// sampleWorld draws a world with sampler, or uniformly when it is nil
+func sampleWorld(sampler WorldSampler, state State) (State, error) {
+	if sampler == nil {
+		sampler = UniformSampler{}
+	}
+	return sampler.SampleWorld(state)
+}
+
+// historyLikelihood is the probability that every seat other than playerID
+// plays the history of world according to model
+func historyLikelihood(model PolicyModel, world *EuchreState, playerID int) (float64, error) {
+	likelihood := 1.0
+	replay := world.startOfPlay()
+	for _, card := range world.playedCards() {
+		if replay.currentAgent != playerID {
+			policy, err := modelPolicy(model, &replay)
+			if err != nil {
+				return 0, err
+			}
+			likelihood *= cardProbability(policy, &replay, card)
+			if likelihood == 0 {
+				return 0, nil
+			}
+		}
+		replay.TakeAction(Action(card))
+	}
+	return likelihood, nil
+}
+
+// modelPolicy returns the policy of model at state. A stored model without
+// an entry for state, or any policy that doesn't sum to 1, is an error
+// since the likelihood would quietly come out uniform or skewed.
+func modelPolicy(model PolicyModel, state State) (map[Action]float64, error) {
+	var policy map[Action]float64
+	if stored, ok := model.(storedPolicyModel); ok {
+		var exists bool
+		policy, exists = stored.storedPolicy(state)
+		if !exists {
+			return nil, fmt.Errorf("The model has no policy for info set %v", state.GetInfoSetKey())
+		}
+	} else {
+		policy = model.Policy(state)
+	}
+
+	total := 0.0
+	for _, prob := range policy {
+		total += prob
+	}
+	if math.Abs(total-1) > 1e-6 {
+		return nil, fmt.Errorf("The model's policy for info set %v sums to %v", state.GetInfoSetKey(), total)
+	}
+	return policy, nil
+}
+
+// cardProbability returns the probability the policy gives to playing card.
+// A card that was collapsed into an equivalent action gets that action's
+// probability.
+func cardProbability(policy map[Action]float64, state *EuchreState, card Card) float64 {
+	if prob, exists := policy[Action(card)]; exists {
+		return prob
+	}
+	for action, prob := range policy {
+		if state.equivalentCards(state.currentAgent, Card(action), card) {
+			return prob
+		}
+	}
+	return 0
+}
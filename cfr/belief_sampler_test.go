@@ -0,0 +1,49 @@
+package cfr
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// unnormalizedModel gives every valid action probability 1
+type unnormalizedModel struct{}
+
+func (model unnormalizedModel) Policy(state State) map[Action]float64 {
+	policy := make(map[Action]float64)
+	for _, action := range state.ValidActions() {
+		policy[action] = 1
+	}
+	return policy
+}
+
+func TestBeliefSamplerWithoutCandidatesSamplesUniformly(t *testing.T) {
+	state := playedInto(rand.New(rand.NewSource(3)), 6)
+	for _, numCandidates := range []int{0, -1} {
+		sampler := BeliefSampler{Model: RandomAgent{}, NumCandidates: numCandidates}
+		world, err := sampler.SampleWorld(&state)
+		if err != nil || world == nil {
+			t.Fatalf("NumCandidates %d sampled %v, %v", numCandidates, world, err)
+		}
+		if world.GetInfoSetKey() != state.GetInfoSetKey() {
+			t.Errorf("NumCandidates %d sampled a world from another info set", numCandidates)
+		}
+	}
+}
+
+// A model that can't say how the history was played is reported rather than
+// taken to play uniformly
+func TestBeliefSamplerReportsBadModels(t *testing.T) {
+	state := playedInto(rand.New(rand.NewSource(3)), 6)
+	untrained := NewStrategy()
+	models := map[string]PolicyModel{
+		"untrained strategy": &untrained,
+		"untrained agent":    StrategyAgent{Strat: &untrained},
+		"unnormalized":       unnormalizedModel{},
+	}
+	for name, model := range models {
+		sampler := BeliefSampler{Model: model, NumCandidates: 4}
+		if world, err := sampler.SampleWorld(&state); err == nil {
+			t.Errorf("%s: sampled %v without an error", name, world)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package cfr
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// KITTY_HOLDER indexes the kitty among the holders of a BeliefState, after
+// the four seats
+const KITTY_HOLDER = 4
+
+// BeliefState tracks what one seat knows about the cards it can't see. For
+// every other seat and the kitty it holds the probability of each unseen
+// card, with every deal consistent with the hand sizes and the suits each
+// seat has shown out of equally likely. Every card played must be passed
+// to Observe. Bids aren't observed, so the beliefs ignore what they reveal.
+type BeliefState struct {
+	Observer  int
+	TrumpSuit Suit
+	Upcard    Card
+
+	hand      []Card
+	handSizes [4]int
+	kittySize int
+	unseen    []Card
+	voids     [4]CardSet
+	trick     []Card
+	probs     [5][24]float64
+}
+
+// NewBeliefState starts tracking from the view of the current agent
+func NewBeliefState(state *EuchreState) *BeliefState {
+	belief := BeliefState{
+		Observer:  state.currentAgent,
+		TrumpSuit: state.TrumpSuit,
+		Upcard:    state.upcard,
+		hand:      state.playerHands[state.currentAgent].Cards(),
+		kittySize: state.kitty.Len(),
+		unseen:    state.kitty.Cards(),
+		voids:     state.voids,
+		trick:     append([]Card{}, state.trick()...),
+	}
+	for seat, hand := range state.playerHands {
+		belief.handSizes[seat] = hand.Len()
+		if seat != belief.Observer {
+			belief.unseen = append(belief.unseen, hand.Cards()...)
+		}
+	}
+
+	belief.update()
+	return &belief
+}
+
+// Observe records seat playing card to the current trick
+func (belief *BeliefState) Observe(seat int, card Card) {
+	if seat == belief.Observer {
+		belief.hand = RemoveValue(belief.hand, card)
+	} else {
+		belief.unseen = RemoveValue(belief.unseen, card)
+	}
+
+	// Not following suit shows the seat is out of the led suit
+	if len(belief.trick) > 0 {
+		leadSuit := belief.trick[0].effectiveSuit(belief.TrumpSuit)
+		if card.effectiveSuit(belief.TrumpSuit) != leadSuit {
+			belief.voids[seat] |= EffectiveSuitMask(leadSuit, belief.TrumpSuit)
+		}
+	}
+
+	belief.handSizes[seat]--
+	belief.trick = append(belief.trick, card)
+	if len(belief.trick) == 4 {
+		belief.trick = belief.trick[:0]
+	}
+	belief.update()
+}
+
+// Prob returns the probability that holder, a seat or KITTY_HOLDER, has
+// card
+func (belief *BeliefState) Prob(holder int, card Card) float64 {
+	return belief.probs[holder][card.index()]
+}
+
+// Unseen returns the cards the observer hasn't seen
+func (belief *BeliefState) Unseen() []Card {
+	return append([]Card{}, belief.unseen...)
+}
+
+// SampleHands deals the unseen cards uniformly among the consistent deals.
+// The kitty starts with the upcard.
+func (belief *BeliefState) SampleHands() ([4][]Card, []Card, error) {
+	layout := belief.layout()
+	dealt, err := layout.sample()
+	if err != nil {
+		return [4][]Card{}, nil, err
+	}
+
+	var hands [4][]Card
+	hands[belief.Observer] = append([]Card{}, belief.hand...)
+	for h, seat := range layout.seats {
+		hands[seat] = dealt[h]
+	}
+	kitty := append([]Card{belief.Upcard}, dealt[3]...)
+	return hands, kitty, nil
+}
+
+// String lays out the probabilities with a row per unseen card
+func (belief *BeliefState) String() string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("%-20s", "Card"))
+	for seat := 0; seat < 4; seat++ {
+		if seat != belief.Observer {
+			builder.WriteString(fmt.Sprintf("%8s", fmt.Sprintf("Seat %d", seat)))
+		}
+	}
+	builder.WriteString(fmt.Sprintf("%8s\n", "Kitty"))
+
+	for _, card := range sortedCards(belief.unseen) {
+		builder.WriteString(fmt.Sprintf("%-20s", card.ToString()))
+		for holder := 0; holder <= KITTY_HOLDER; holder++ {
+			if holder != belief.Observer {
+				builder.WriteString(fmt.Sprintf("%8.3f", belief.Prob(holder, card)))
+			}
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+func (belief *BeliefState) layout() *hiddenLayout {
+	var seats [3]int
+	var capacity [4]int
+	for h := range seats {
+		seats[h] = (belief.Observer + h + 1) % 4
+		capacity[h] = belief.handSizes[seats[h]]
+	}
+	capacity[3] = belief.kittySize
+	return newHiddenLayout(belief.unseen, belief.TrumpSuit, seats, capacity, belief.voids)
+}
+
+// update recomputes the probabilities from the unseen cards and voids
+func (belief *BeliefState) update() {
+	belief.probs = [5][24]float64{}
+	for _, card := range belief.hand {
+		belief.probs[belief.Observer][card.index()] = 1
+	}
+
+	layout := belief.layout()
+	holdProbs := layout.holdProbabilities()
+	for g, group := range layout.groups {
+		for _, card := range group {
+			for h, seat := range layout.seats {
+				belief.probs[seat][card.index()] = holdProbs[g][h]
+			}
+			belief.probs[KITTY_HOLDER][card.index()] = holdProbs[g][3]
+		}
+	}
+}
+
+func sortedCards(cards []Card) []Card {
+	sorted := append([]Card{}, cards...)
+	sort.Slice(sorted, func(j, k int) bool {
+		return sorted[j] < sorted[k]
+	})
+	return sorted
+}
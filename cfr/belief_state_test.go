@@ -0,0 +1,117 @@
+package cfr
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// beliefStates are positions from the view of the seat to act, some with
+// voids
+func beliefStates(r *rand.Rand) []EuchreState {
+	states := make([]EuchreState, 0)
+	for len(states) < 60 {
+		if state := playedInto(r, r.Intn(16)); !state.IsTerminal() {
+			states = append(states, state)
+		}
+	}
+	return states
+}
+
+func TestBeliefProbabilitiesSumToOne(t *testing.T) {
+	for _, state := range beliefStates(rand.New(rand.NewSource(1))) {
+		belief := NewBeliefState(&state)
+		for _, card := range belief.Unseen() {
+			total := 0.0
+			for holder := 0; holder <= KITTY_HOLDER; holder++ {
+				if holder != belief.Observer {
+					total += belief.Prob(holder, card)
+				}
+			}
+			if math.Abs(total-1) > 1e-9 {
+				t.Fatalf("The holders of %s sum to %v\n%v", card.ToString(), total, belief)
+			}
+		}
+	}
+}
+
+func TestBeliefRulesOutKnownCards(t *testing.T) {
+	withVoids := 0
+	for _, state := range beliefStates(rand.New(rand.NewSource(2))) {
+		belief := NewBeliefState(&state)
+		for holder := 0; holder <= KITTY_HOLDER; holder++ {
+			if holder == belief.Observer {
+				continue
+			}
+			if prob := belief.Prob(holder, belief.Upcard); prob != 0 {
+				t.Fatalf("Holder %d has the upcard with probability %v", holder, prob)
+			}
+			for _, card := range state.PlayedCards() {
+				if prob := belief.Prob(holder, card); prob != 0 {
+					t.Fatalf("Holder %d has the played %s with probability %v", holder, card.ToString(), prob)
+				}
+			}
+			for _, card := range state.playerHands[belief.Observer].Cards() {
+				if prob := belief.Prob(holder, card); prob != 0 {
+					t.Fatalf("Holder %d has the observer's %s with probability %v", holder, card.ToString(), prob)
+				}
+			}
+		}
+
+		for seat, voids := range state.voids {
+			if seat == belief.Observer || voids.Empty() {
+				continue
+			}
+			withVoids++
+			for _, card := range belief.Unseen() {
+				if voids.Contains(card) && belief.Prob(seat, card) != 0 {
+					t.Fatalf("Seat %d is out of %s but has it with probability %v",
+						seat, card.ToString(), belief.Prob(seat, card))
+				}
+			}
+		}
+	}
+	if withVoids == 0 {
+		t.Error("No position had a void, so the voids went untested")
+	}
+}
+
+// The probabilities match the share of consistent deals holding each card
+func TestBeliefMatchesEnumeration(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	compared := 0
+	for trial := 0; trial < 20; trial++ {
+		state := playedInto(r, 10+r.Intn(6))
+		if state.IsTerminal() {
+			continue
+		}
+		worlds, ok := state.EnumerateWorlds(5000)
+		if !ok {
+			continue
+		}
+
+		compared++
+		belief := NewBeliefState(&state)
+		for _, card := range belief.Unseen() {
+			for seat := 0; seat < 4; seat++ {
+				if seat == belief.Observer {
+					continue
+				}
+				holding := 0
+				for _, world := range worlds {
+					if world.playerHands[seat].Contains(card) {
+						holding++
+					}
+				}
+				expected := float64(holding) / float64(len(worlds))
+				if math.Abs(belief.Prob(seat, card)-expected) > 1e-9 {
+					t.Fatalf("Seat %d has %s with probability %v, %v of the deals",
+						seat, card.ToString(), belief.Prob(seat, card), expected)
+				}
+			}
+		}
+	}
+	if compared == 0 {
+		t.Error("Every position had too many deals to enumerate")
+	}
+}
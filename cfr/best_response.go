@@ -0,0 +1,152 @@
+package cfr
+
+import "math"
+
+// ExploitabilityReport summarizes how far a strategy is from an equilibrium
+type ExploitabilityReport struct {
+	// Utility of each player when every agent follows the strategy
+	PolicyValues []float64
+	// Utility of each player when they best respond and everyone else
+	// follows the strategy
+	BestResponseValues []float64
+	// Sum over players of what a best response gains over the strategy.
+	// This is zero exactly at a Nash equilibrium. In two player zero sum
+	// games the exploitability is half of it.
+	NashConv float64
+}
+
+// Exploitability computes every player's best response value against the
+// average strategy. The full tree under root is walked several times so
+// this is only practical on small games such as Kuhn, Leduc or tiny Euchre
+// variants.
+func (strat *Strategy) Exploitability(root State, numPlayers int) ExploitabilityReport {
+	report := ExploitabilityReport{
+		PolicyValues:       make([]float64, numPlayers),
+		BestResponseValues: make([]float64, numPlayers),
+	}
+
+	for playerID := 0; playerID < numPlayers; playerID++ {
+		report.PolicyValues[playerID] = strat.ExpectedUtility(root, playerID)
+		report.BestResponseValues[playerID] = strat.BestResponse(root, playerID)
+		report.NashConv += report.BestResponseValues[playerID] - report.PolicyValues[playerID]
+	}
+
+	return report
+}
+
+// BestResponse computes the value playerID gets by best responding to the
+// average strategy of every other agent. States are grouped into info sets
+// by GetInfoSetKey, so the result is exact when the keys give the best
+// responder perfect recall. With an abstracted key it is the best response
+// of a player restricted to that abstraction.
+func (strat *Strategy) BestResponse(root State, playerID int) float64 {
+	br := bestResponse{
+		strat:       strat,
+		playerID:    playerID,
+		infoSets:    make(map[InfoSetKey][]reachedState),
+		bestActions: make(map[InfoSetKey]Action),
+	}
+	br.collect(root, 1.0)
+
+	return br.value(root)
+}
+
+// reachedState is a state in the best responder's info set along with the
+// probability that chance and the other agents play to it
+type reachedState struct {
+	state State
+	reach float64
+}
+
+type bestResponse struct {
+	strat       *Strategy
+	playerID    int
+	infoSets    map[InfoSetKey][]reachedState
+	bestActions map[InfoSetKey]Action
+}
+
+// collect groups every reachable decision of the best responder by info set
+func (br *bestResponse) collect(state State, reach float64) {
+	if state.IsTerminal() || reach == 0 {
+		return
+	}
+
+	if state.IsChanceNode() {
+		outcomes, probs := state.ChanceOutcomes()
+		for i, outcome := range outcomes {
+			br.collect(state.TakeActionCopy(outcome), reach*probs[i])
+		}
+		return
+	}
+
+	if state.GetCurrentAgent() != br.playerID {
+		for action, prob := range br.strat.Policy(state) {
+			br.collect(state.TakeActionCopy(action), reach*prob)
+		}
+		return
+	}
+
+	validActions := state.ValidActions()
+	if len(validActions) > 1 {
+		key := state.GetInfoSetKey()
+		br.infoSets[key] = append(br.infoSets[key], reachedState{state: state, reach: reach})
+	}
+	for _, action := range validActions {
+		br.collect(state.TakeActionCopy(action), reach)
+	}
+}
+
+// value returns the best responder's utility below state
+func (br *bestResponse) value(state State) float64 {
+	if state.IsTerminal() {
+		return state.GetUtility(br.playerID)
+	}
+
+	utility := 0.0
+	if state.IsChanceNode() {
+		outcomes, probs := state.ChanceOutcomes()
+		for i, outcome := range outcomes {
+			utility += probs[i] * br.value(state.TakeActionCopy(outcome))
+		}
+		return utility
+	}
+
+	if state.GetCurrentAgent() != br.playerID {
+		for action, prob := range br.strat.Policy(state) {
+			if prob > 0 {
+				utility += prob * br.value(state.TakeActionCopy(action))
+			}
+		}
+		return utility
+	}
+
+	validActions := state.ValidActions()
+	if len(validActions) == 1 {
+		return br.value(state.TakeActionCopy(validActions[0]))
+	}
+	return br.value(state.TakeActionCopy(br.bestAction(state.GetInfoSetKey(), validActions)))
+}
+
+// bestAction picks the action that maximizes the reach weighted value over
+// every state in the info set
+func (br *bestResponse) bestAction(key InfoSetKey, validActions []Action) Action {
+	if action, exists := br.bestActions[key]; exists {
+		return action
+	}
+
+	bestAction := validActions[0]
+	bestValue := math.Inf(-1)
+	for _, action := range validActions {
+		actionValue := 0.0
+		for _, reached := range br.infoSets[key] {
+			actionValue += reached.reach * br.value(reached.state.TakeActionCopy(action))
+		}
+		if actionValue > bestValue {
+			bestValue = actionValue
+			bestAction = action
+		}
+	}
+
+	br.bestActions[key] = bestAction
+	return bestAction
+}
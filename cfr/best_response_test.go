@@ -0,0 +1,63 @@
+package cfr
+
+import (
+	"math"
+	"testing"
+)
+
+func kuhnRoot() State {
+	root := NewKuhnState()
+	return &root
+}
+
+func leducRoot() State {
+	root := NewLeducState()
+	return &root
+}
+
+// trainCFR runs vanilla CFR iterations for both players of a two player game
+func trainCFR(strat *Strategy, newRoot func() State, iterations int) {
+	for i := 0; i < iterations; i++ {
+		for playerID := 0; playerID < 2; playerID++ {
+			strat.CFR(playerID, newRoot(), []float64{1, 1})
+		}
+	}
+}
+
+func TestKuhnCFRConverges(t *testing.T) {
+	strat := NewStrategy()
+	trainCFR(&strat, kuhnRoot, 100)
+	early := strat.Exploitability(kuhnRoot(), 2)
+	trainCFR(&strat, kuhnRoot, 4900)
+	late := strat.Exploitability(kuhnRoot(), 2)
+	t.Logf("NashConv %f after 100 iterations, %f after 5000", early.NashConv, late.NashConv)
+
+	if late.NashConv < -1e-9 || late.NashConv >= early.NashConv {
+		t.Errorf("NashConv should fall towards 0, went from %f to %f", early.NashConv, late.NashConv)
+	}
+	if late.NashConv > 0.01 {
+		t.Errorf("NashConv after 5000 iterations is %f", late.NashConv)
+	}
+	if value := late.PolicyValues[0]; math.Abs(value+1.0/18) > 0.005 {
+		t.Errorf("The first player should lose 1/18 per hand, got %f", value)
+	}
+	if sum := late.PolicyValues[0] + late.PolicyValues[1]; math.Abs(sum) > 1e-9 {
+		t.Errorf("Kuhn is zero sum but the values add to %f", sum)
+	}
+}
+
+func TestLeducCFRSmoke(t *testing.T) {
+	strat := NewStrategy()
+	trainCFR(&strat, leducRoot, 1)
+	early := strat.Exploitability(leducRoot(), 2)
+	trainCFR(&strat, leducRoot, 49)
+	late := strat.Exploitability(leducRoot(), 2)
+	t.Logf("NashConv %f after 1 iteration, %f after 50", early.NashConv, late.NashConv)
+
+	if math.IsNaN(late.NashConv) || late.NashConv < -1e-9 || late.NashConv >= early.NashConv {
+		t.Errorf("NashConv should fall towards 0, went from %f to %f", early.NashConv, late.NashConv)
+	}
+	if sum := late.PolicyValues[0] + late.PolicyValues[1]; math.Abs(sum) > 1e-9 {
+		t.Errorf("Leduc is zero sum but the values add to %f", sum)
+	}
+}
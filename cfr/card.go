@@ -136,10 +136,18 @@ func (c *Card) getValue() Value {
 	return Value(int(*c) % 10)
 }
 
+// index maps the card to 0-23, ordered by suit then value
+func (c Card) index() int {
+	return (int(c.getSuit())/10-1)*6 + int(c.getValue()) - 1
+}
+
 func (c *Card) normalizeSuit(suit Suit) {
 	*c = Card(int(c.getSuit().normalizeSuit(suit)) + int(c.getValue()))
 }
 
+// getRankings lists the cards from weakest to strongest in a trick led in
+// leadSuit. Cards outside trump and the lead suit can't take the trick and
+// come first.
 func getRankings(trumpSuit Suit, leadSuit Suit) []Card {
 	rightBower := makeCard(trumpSuit, JACK)
 	leftBower := makeCard(trumpSuit.complement(), JACK)
@@ -148,7 +156,7 @@ func getRankings(trumpSuit Suit, leadSuit Suit) []Card {
 	// Add off suits
 	for s := 10; s <= 40; s += 10 {
 		if (Suit(s) != trumpSuit) && (Suit(s) != leadSuit) {
-			for v := 6; v > 0; v-- {
+			for v := 1; v <= 6; v++ {
 				card := makeCard(Suit(s), Value(v))
 				if card != leftBower {
 					ranks = append(ranks, card)
@@ -168,7 +176,7 @@ func getRankings(trumpSuit Suit, leadSuit Suit) []Card {
 	}
 
 	// Add trump
-	for v := 6; v > 0; v-- {
+	for v := 1; v <= 6; v++ {
 		card := makeCard(trumpSuit, Value(v))
 		if card != rightBower {
 			ranks = append(ranks, card)
@@ -186,11 +194,45 @@ func (c Card) ToString() string {
 	return fmt.Sprintf("%s of %s", c.getValue().toString(), c.getSuit().toString())
 }
 
-func getRank(c Card, ranks []Card) int {
-	for idx, card := range ranks {
-		if card == c {
-			return idx
+// rankTable holds getRankings for one trump and lead suit in the form
+// tricks look it up
+type rankTable struct {
+	// ranks holds the position of each card, by index, in the rankings
+	ranks [24]uint8
+	// below holds, for each rank, the cards ranked under it
+	below [25]CardSet
+}
+
+// rankTables is indexed by trump, then lead suit, each as suit/10 - 1
+var rankTables = makeRankTables()
+
+func makeRankTables() [4][4]rankTable {
+	var tables [4][4]rankTable
+	for t := DIAMONDS; t <= CLUBS; t += 10 {
+		for l := DIAMONDS; l <= CLUBS; l += 10 {
+			table := &tables[t/10-1][l/10-1]
+			for rank, card := range getRankings(t, l) {
+				table.ranks[card.index()] = uint8(rank)
+				table.below[rank+1] = table.below[rank].Add(card)
+			}
 		}
 	}
-	panic("Card not in rankings")
+	return tables
+}
+
+// getRankTable returns the rankings of a trick led in leadSuit with
+// trumpSuit trump
+func getRankTable(trumpSuit Suit, leadSuit Suit) *rankTable {
+	return &rankTables[trumpSuit/10-1][leadSuit/10-1]
+}
+
+// rank returns the position of card in the rankings. The higher ranked of
+// two cards takes the trick.
+func (table *rankTable) rank(card Card) int {
+	return int(table.ranks[card.index()])
+}
+
+// between returns the cards ranked strictly between the ranks low and high
+func (table *rankTable) between(low int, high int) CardSet {
+	return table.below[high] &^ table.below[low+1]
 }
@@ -0,0 +1,140 @@
+package cfr
+
+import (
+	"math/bits"
+	"strings"
+)
+
+// CardSet is a set of Euchre cards with one bit per card, at the card's
+// index. Iterating a set visits the cards in ascending order, the order
+// hands are kept in.
+type CardSet uint32
+
+// ALL_CARDS holds the whole 24 card deck
+const ALL_CARDS = CardSet(1<<24 - 1)
+
+// NewCardSet returns the set holding cards
+func NewCardSet(cards ...Card) CardSet {
+	var set CardSet
+	for _, card := range cards {
+		set = set.Add(card)
+	}
+	return set
+}
+
+// cardBit is the set holding only card
+func cardBit(card Card) CardSet {
+	return 1 << uint(card.index())
+}
+
+// Contains reports whether card is in the set
+func (set CardSet) Contains(card Card) bool {
+	return set&cardBit(card) != 0
+}
+
+// Add returns the set with card added
+func (set CardSet) Add(card Card) CardSet {
+	return set | cardBit(card)
+}
+
+// Remove returns the set with card removed
+func (set CardSet) Remove(card Card) CardSet {
+	return set &^ cardBit(card)
+}
+
+// Union returns the cards in either set
+func (set CardSet) Union(other CardSet) CardSet {
+	return set | other
+}
+
+// Intersect returns the cards in both sets
+func (set CardSet) Intersect(other CardSet) CardSet {
+	return set & other
+}
+
+// Minus returns the cards in set but not in other
+func (set CardSet) Minus(other CardSet) CardSet {
+	return set &^ other
+}
+
+// Len returns the number of cards in the set
+func (set CardSet) Len() int {
+	return bits.OnesCount32(uint32(set))
+}
+
+// Empty reports whether the set holds no cards
+func (set CardSet) Empty() bool {
+	return set == 0
+}
+
+// Lowest returns the lowest card in a set that isn't empty
+func (set CardSet) Lowest() Card {
+	return cardFromIndex(bits.TrailingZeros32(uint32(set)))
+}
+
+// Highest returns the highest card in a set that isn't empty
+func (set CardSet) Highest() Card {
+	return cardFromIndex(31 - bits.LeadingZeros32(uint32(set)))
+}
+
+// Cards returns the cards of the set in ascending order
+func (set CardSet) Cards() []Card {
+	cards := make([]Card, 0, set.Len())
+	for rest := set; rest != 0; rest &= rest - 1 {
+		cards = append(cards, rest.Lowest())
+	}
+	return cards
+}
+
+// String lists the cards of the set
+func (set CardSet) String() string {
+	names := make([]string, 0, set.Len())
+	for _, card := range set.Cards() {
+		names = append(names, card.ToString())
+	}
+	return "[" + strings.Join(names, ", ") + "]"
+}
+
+// SuitMask returns the six cards printed with suit
+func SuitMask(suit Suit) CardSet {
+	return CardSet(0x3f) << uint((suit/10-1)*6)
+}
+
+// EffectiveSuitMask returns the cards that follow suit when trumpSuit is
+// trump: the left bower belongs to trump and not to its printed suit
+func EffectiveSuitMask(suit Suit, trumpSuit Suit) CardSet {
+	return effectiveSuitMasks[trumpSuit/10-1][suit/10-1]
+}
+
+// effectiveSuitMasks is indexed by trump, then suit, each as suit/10 - 1
+var effectiveSuitMasks = makeEffectiveSuitMasks()
+
+func makeEffectiveSuitMasks() [4][4]CardSet {
+	var masks [4][4]CardSet
+	for t := 10; t <= 40; t += 10 {
+		trumpSuit := Suit(t)
+		leftBower := cardBit(makeCard(trumpSuit.complement(), JACK))
+		for s := 10; s <= 40; s += 10 {
+			mask := SuitMask(Suit(s))
+			switch Suit(s) {
+			case trumpSuit:
+				mask |= leftBower
+			case trumpSuit.complement():
+				mask &^= leftBower
+			}
+			masks[t/10-1][s/10-1] = mask
+		}
+	}
+	return masks
+}
+
+// relabel renames the suits of every card in the set with mapSuit, which
+// must be a permutation of the suits
+func (set CardSet) relabel(mapSuit func(Suit) Suit) CardSet {
+	var relabeled CardSet
+	for s := DIAMONDS; s <= CLUBS; s += 10 {
+		cards := (set & SuitMask(s)) >> uint((s/10-1)*6)
+		relabeled |= cards << uint((mapSuit(s)/10-1)*6)
+	}
+	return relabeled
+}
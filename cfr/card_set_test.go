@@ -0,0 +1,87 @@
+package cfr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCardSet(t *testing.T) {
+	nineD, aceD := makeCard(DIAMONDS, NINE), makeCard(DIAMONDS, ACE)
+	jackS, jackC := makeCard(SPADES, JACK), makeCard(CLUBS, JACK)
+	aceC := makeCard(CLUBS, ACE)
+
+	tests := []struct {
+		name    string
+		set     CardSet
+		cards   []Card
+		highest Card
+	}{
+		{"one card", NewCardSet(jackS), []Card{jackS}, jackS},
+		{"added out of order", NewCardSet(aceC, nineD, jackS), []Card{nineD, jackS, aceC}, aceC},
+		{"added twice", NewCardSet(jackC).Add(jackC).Add(nineD), []Card{nineD, jackC}, jackC},
+		{"removed", NewCardSet(nineD, aceD, aceC).Remove(aceC), []Card{nineD, aceD}, aceD},
+		{"removing a missing card", NewCardSet(jackS).Remove(jackC), []Card{jackS}, jackS},
+		{"minus", NewCardSet(nineD, jackS, jackC, aceC).Minus(NewCardSet(jackC, aceC, aceD)), []Card{nineD, jackS}, jackS},
+		{"union", NewCardSet(aceD).Union(NewCardSet(nineD, aceC)), []Card{nineD, aceD, aceC}, aceC},
+		{"intersect", NewCardSet(nineD, jackS).Intersect(NewCardSet(jackS, jackC)), []Card{jackS}, jackS},
+	}
+
+	for _, test := range tests {
+		if cards := test.set.Cards(); !reflect.DeepEqual(cards, test.cards) {
+			t.Errorf("%s: iterated %v, expected %v", test.name, cards, test.cards)
+		}
+		if test.set.Len() != len(test.cards) {
+			t.Errorf("%s: has %d cards, expected %d", test.name, test.set.Len(), len(test.cards))
+		}
+		if highest := test.set.Highest(); highest != test.highest {
+			t.Errorf("%s: highest card is %v, expected %v", test.name, highest, test.highest)
+		}
+		if lowest := test.set.Lowest(); lowest != test.cards[0] {
+			t.Errorf("%s: lowest card is %v, expected %v", test.name, lowest, test.cards[0])
+		}
+		for _, card := range ALL_CARDS.Cards() {
+			expected := false
+			for _, held := range test.cards {
+				expected = expected || held == card
+			}
+			if test.set.Contains(card) != expected {
+				t.Errorf("%s: contains %v is %v, expected %v", test.name, card, !expected, expected)
+			}
+		}
+	}
+
+	if !CardSet(0).Empty() || NewCardSet(nineD).Empty() {
+		t.Error("Only the set without cards is empty")
+	}
+	if ALL_CARDS.Len() != 24 || ALL_CARDS.Lowest() != nineD || ALL_CARDS.Highest() != aceC {
+		t.Errorf("The deck holds %v", ALL_CARDS)
+	}
+}
+
+func TestEffectiveSuitMask(t *testing.T) {
+	tests := []struct {
+		name      string
+		suit      Suit
+		trumpSuit Suit
+		cards     int
+		holds     Card
+		excludes  Card
+	}{
+		{"trump takes the left bower", SPADES, SPADES, 7, makeCard(CLUBS, JACK), makeCard(HEARTS, JACK)},
+		{"the left bower's suit loses it", CLUBS, SPADES, 5, makeCard(CLUBS, ACE), makeCard(CLUBS, JACK)},
+		{"the other colour is unchanged", HEARTS, SPADES, 6, makeCard(HEARTS, JACK), makeCard(DIAMONDS, JACK)},
+		{"red trump takes the jack of diamonds", HEARTS, HEARTS, 7, makeCard(DIAMONDS, JACK), makeCard(SPADES, JACK)},
+	}
+
+	for _, test := range tests {
+		mask := EffectiveSuitMask(test.suit, test.trumpSuit)
+		if mask.Len() != test.cards || !mask.Contains(test.holds) || mask.Contains(test.excludes) {
+			t.Errorf("%s: got %v", test.name, mask)
+		}
+		for _, card := range mask.Cards() {
+			if card.effectiveSuit(test.trumpSuit) != test.suit {
+				t.Errorf("%s: %v doesn't follow %v", test.name, card, test.suit)
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package cfr
+
+import "testing"
+
+func TestWinningIndex(t *testing.T) {
+	tests := []struct {
+		name      string
+		trumpSuit Suit
+		trick     []Card
+		winner    int
+	}{
+		{
+			name:      "trump ace beats the trump nine",
+			trumpSuit: SPADES,
+			trick:     []Card{makeCard(SPADES, ACE), makeCard(SPADES, NINE), makeCard(SPADES, TEN), makeCard(SPADES, KING)},
+			winner:    0,
+		},
+		{
+			name:      "trumps rank nine, ten, queen, king, ace",
+			trumpSuit: HEARTS,
+			trick:     []Card{makeCard(HEARTS, NINE), makeCard(HEARTS, TEN), makeCard(HEARTS, QUEEN), makeCard(HEARTS, KING)},
+			winner:    3,
+		},
+		{
+			name:      "left bower beats the trump ace",
+			trumpSuit: SPADES,
+			trick:     []Card{makeCard(SPADES, ACE), makeCard(CLUBS, JACK), makeCard(SPADES, KING), makeCard(SPADES, QUEEN)},
+			winner:    1,
+		},
+		{
+			name:      "right bower beats the left bower",
+			trumpSuit: DIAMONDS,
+			trick:     []Card{makeCard(HEARTS, JACK), makeCard(DIAMONDS, ACE), makeCard(DIAMONDS, JACK), makeCard(DIAMONDS, NINE)},
+			winner:    2,
+		},
+		{
+			name:      "left bower led is trump, not its printed suit",
+			trumpSuit: CLUBS,
+			trick:     []Card{makeCard(SPADES, JACK), makeCard(SPADES, ACE), makeCard(CLUBS, NINE), makeCard(HEARTS, ACE)},
+			winner:    0,
+		},
+		{
+			name:      "lead suit ace beats lower cards of the lead suit",
+			trumpSuit: SPADES,
+			trick:     []Card{makeCard(HEARTS, KING), makeCard(HEARTS, ACE), makeCard(HEARTS, NINE), makeCard(HEARTS, QUEEN)},
+			winner:    1,
+		},
+		{
+			name:      "trump nine beats the lead ace",
+			trumpSuit: SPADES,
+			trick:     []Card{makeCard(HEARTS, ACE), makeCard(DIAMONDS, ACE), makeCard(SPADES, NINE), makeCard(CLUBS, ACE)},
+			winner:    2,
+		},
+		{
+			name:      "off suit aces can't take the trick",
+			trumpSuit: SPADES,
+			trick:     []Card{makeCard(HEARTS, NINE), makeCard(DIAMONDS, ACE), makeCard(CLUBS, ACE), makeCard(HEARTS, TEN)},
+			winner:    3,
+		},
+	}
+
+	for _, test := range tests {
+		if len(getRankings(test.trumpSuit, test.trick[0].effectiveSuit(test.trumpSuit))) != 24 {
+			t.Fatalf("%s: rankings don't hold every card", test.name)
+		}
+		if winner := winningIndex(test.trick, test.trumpSuit); winner != test.winner {
+			t.Errorf("%s: card %d won, expected card %d", test.name, winner, test.winner)
+		}
+	}
+}
+
+func TestRankTablesMatchRankings(t *testing.T) {
+	for trumpSuit := DIAMONDS; trumpSuit <= CLUBS; trumpSuit += 10 {
+		for leadSuit := DIAMONDS; leadSuit <= CLUBS; leadSuit += 10 {
+			rankings := getRankings(trumpSuit, leadSuit)
+			if len(rankings) != 24 {
+				t.Fatalf("Rankings hold %d cards", len(rankings))
+			}
+			table := getRankTable(trumpSuit, leadSuit)
+			for rank, card := range rankings {
+				if table.rank(card) != rank {
+					t.Errorf("%s ranks %d in the table and %d in the rankings", card.ToString(), table.rank(card), rank)
+				}
+			}
+			for low := range rankings {
+				for high := low + 1; high < len(rankings); high++ {
+					if between, expected := table.between(low, high), NewCardSet(rankings[low+1:high]...); between != expected {
+						t.Errorf("Between ranks %d and %d with %v trump and %v led the table holds %v, expected %v",
+							low, high, trumpSuit, leadSuit, between, expected)
+					}
+				}
+			}
+		}
+	}
+}
@@ -10,33 +10,49 @@ type (
 	InfoSetKey string
 )
 
-// InfoSet ...
+// InfoSet holds what CFR has learned at one info set. The float slices
+// are aligned with Actions, the abstract actions open there.
 type InfoSet struct {
-	CumulativeStrategySum map[Action]float64
-	CumulativeRegret      map[Action]float64
-	CurrentStrategy       map[Action]float64
+	Actions               []Action
+	CumulativeStrategySum []float64
+	CumulativeRegret      []float64
+	CurrentStrategy       []float64
 }
 
 func (info InfoSet) getStateStrategy() map[Action]float64 {
 	var norm float64 = 0.0
-	stateStrategy := make(map[Action]float64)
-	for action, regret := range info.CumulativeRegret {
-		if regret > 0 {
-			stateStrategy[action] = regret
+	for _, regret := range info.CumulativeRegret {
+		norm += math.Max(regret, 0)
+	}
+
+	stateStrategy := make(map[Action]float64, len(info.Actions))
+	for i, action := range info.Actions {
+		if norm > 0 {
+			stateStrategy[action] = math.Max(info.CumulativeRegret[i], 0) / norm
 		} else {
-			stateStrategy[action] = 0
+			stateStrategy[action] = 1.0 / float64(len(info.Actions))
 		}
-		norm += stateStrategy[action]
+	}
+	return stateStrategy
+}
+
+// getAverageStrategy returns the normalized cumulative strategy, which
+// is the policy that converges to an equilibrium
+func (info InfoSet) getAverageStrategy() map[Action]float64 {
+	norm := 0.0
+	for _, weight := range info.CumulativeStrategySum {
+		norm += weight
 	}
 
-	for action := range info.CumulativeStrategySum {
+	avgStrategy := make(map[Action]float64, len(info.Actions))
+	for i, action := range info.Actions {
 		if norm > 0 {
-			stateStrategy[action] = stateStrategy[action] / norm
+			avgStrategy[action] = info.CumulativeStrategySum[i] / norm
 		} else {
-			stateStrategy[action] = 1.0 / float64(len(info.CumulativeRegret))
+			avgStrategy[action] = 1.0 / float64(len(info.Actions))
 		}
 	}
-	return stateStrategy
+	return avgStrategy
 }
 
 func sampleAction(stateStrat map[Action]float64) Action {
@@ -53,109 +69,399 @@ func sampleAction(stateStrat map[Action]float64) Action {
 
 func (info *InfoSet) updateStrategy() {
 	normalizingSum := 0.0
-	for action, regret := range info.CumulativeRegret {
-		info.CurrentStrategy[action] = math.Max(regret, 0)
-		normalizingSum += info.CurrentStrategy[action]
+	for i, regret := range info.CumulativeRegret {
+		info.CurrentStrategy[i] = math.Max(regret, 0)
+		normalizingSum += info.CurrentStrategy[i]
 	}
-	for action := range info.CumulativeRegret {
+	for i := range info.CurrentStrategy {
 		if normalizingSum > 0 {
-			info.CurrentStrategy[action] /= normalizingSum
+			info.CurrentStrategy[i] /= normalizingSum
 		} else {
-			info.CurrentStrategy[action] = 1.0 / float64(len(info.CumulativeRegret))
+			info.CurrentStrategy[i] = 1.0 / float64(len(info.CurrentStrategy))
 		}
 	}
 }
 
-func makeInfoSet(ValidActions []Action) InfoSet {
-	info := InfoSet{
-		CumulativeStrategySum: make(map[Action]float64),
-		CumulativeRegret:      make(map[Action]float64),
-		CurrentStrategy:       make(map[Action]float64),
+// makeInfoSet starts an info set with a uniform strategy. Its three float
+// slices share one allocation.
+func makeInfoSet(validActions []Action) *InfoSet {
+	n := len(validActions)
+	values := make([]float64, 3*n)
+	info := &InfoSet{
+		Actions:               append([]Action(nil), validActions...),
+		CumulativeStrategySum: values[:n:n],
+		CumulativeRegret:      values[n : 2*n : 2*n],
+		CurrentStrategy:       values[2*n:],
 	}
-
-	for index := range ValidActions {
-		info.CumulativeStrategySum[ValidActions[index]] = 0.0
-		info.CumulativeRegret[ValidActions[index]] = 0.0
-		info.CurrentStrategy[ValidActions[index]] = 1.0 / float64(len(ValidActions))
+	for i := range info.CurrentStrategy {
+		info.CurrentStrategy[i] = 1.0 / float64(n)
 	}
 
 	return info
 }
 
+// Strategy stores the info sets learned by CFR. It is safe for concurrent
+// use, so several goroutines can train or read the same Strategy.
 type Strategy struct {
-	InfoSetMap map[InfoSetKey]InfoSet
+	shards []*infoSetShard
+	// Pruning is used by PruningCFR. The zero value never prunes.
+	Pruning RegretPruning
+	// Abstraction maps states to the info sets learned. Nil uses the
+	// game's own info sets and actions.
+	Abstraction Abstraction
+	// Endgame, if set, ends traversals at positions it covers and returns
+	// their double dummy value. The players can't really see each other's
+	// cards, so this approximates the value of the endgame rather than
+	// solving it, and the info sets below those positions are never
+	// trained.
+	Endgame *EndgameTable
+}
+
+// RegretPruning configures regret-based pruning. An action of the
+// traversing player is skipped when its cumulative regret is below
+// Threshold and regret matching gives it zero probability. Every
+// RevisitInterval iterations the whole tree is traversed so pruned actions
+// can recover.
+type RegretPruning struct {
+	Threshold       float64
+	RevisitInterval int
 }
 
 func NewStrategy() Strategy {
 	return Strategy{
-		InfoSetMap: make(map[InfoSetKey]InfoSet),
+		shards: newShards(),
+	}
+}
+
+// Policy returns the average strategy at state in the game's actions. Info
+// sets that were never trained fall back to a uniform policy over the
+// abstract actions.
+func (strat *Strategy) Policy(state State) map[Action]float64 {
+	policy, _ := strat.storedPolicy(state)
+	return policy
+}
+
+// storedPolicy is Policy that also reports whether the policy was trained
+// rather than filled in uniformly. A single abstract action needs no
+// training.
+func (strat *Strategy) storedPolicy(state State) (map[Action]float64, bool) {
+	validActions := strat.actions(state)
+	policy := make(map[Action]float64)
+	if len(validActions) > 1 {
+		if avgStrategy, exists := strat.averageStrategy(strat.infoSetKey(state)); exists {
+			for action, prob := range avgStrategy {
+				policy[strat.concreteAction(state, action)] = prob
+			}
+			return policy, true
+		}
 	}
+
+	for _, action := range validActions {
+		policy[strat.concreteAction(state, action)] = 1.0 / float64(len(validActions))
+	}
+	return policy, len(validActions) <= 1
+}
+
+// ExpectedUtility computes the exact utility for playerID when every agent
+// follows the average strategy, weighting chance outcomes by their
+// probability. The whole tree is walked so this is for small games only.
+func (strat *Strategy) ExpectedUtility(state State, playerID int) float64 {
+	if state.IsTerminal() {
+		return state.GetUtility(playerID)
+	}
+
+	utility := 0.0
+	if state.IsChanceNode() {
+		outcomes, probs := state.ChanceOutcomes()
+		for i, outcome := range outcomes {
+			utility += probs[i] * strat.ExpectedUtility(applyAction(state, outcome), playerID)
+			undoAction(state, outcome)
+		}
+		return utility
+	}
+
+	for action, prob := range strat.Policy(state) {
+		if prob > 0 {
+			utility += prob * strat.ExpectedUtility(applyAction(state, action), playerID)
+			undoAction(state, action)
+		}
+	}
+	return utility
 }
 
 type State interface {
 	ValidActions() []Action
 	// Should return a pointer to the same state object to minimize copying
-	TakeAction(Action, bool) State
+	TakeAction(Action) State
 	// Pass by value so the state is duplicated
 	TakeActionCopy(Action) State
 	IsTerminal() bool
+	// IsChanceNode reports whether the next action is drawn by chance
+	// (a deal, a card flip) instead of being chosen by an agent
+	IsChanceNode() bool
+	// ChanceOutcomes returns the possible chance actions and the
+	// probability of each. Only valid when IsChanceNode is true
+	ChanceOutcomes() ([]Action, []float64)
 	GetCurrentAgent() int
 	GetUtility(playerID int) float64
 	GetInfoSetKey() InfoSetKey
 }
 
+// Undoable is implemented by states that can take back the last action
+// they took. Searches walk the tree of an Undoable state by changing it in
+// place instead of copying it at every node.
+type Undoable interface {
+	State
+	// UndoAction takes back action, which must be the last action taken
+	UndoAction(Action)
+}
+
+// applyAction returns the child of state after action for a search. An
+// Undoable state is changed in place and must be restored with undoAction
+// once the child has been searched. Every other state is copied.
+func applyAction(state State, action Action) State {
+	if _, ok := state.(Undoable); ok {
+		return state.TakeAction(action)
+	}
+	return state.TakeActionCopy(action)
+}
+
+// undoAction restores state after searching the child applyAction returned
+func undoAction(state State, action Action) {
+	if undoable, ok := state.(Undoable); ok {
+		undoable.UndoAction(action)
+	}
+}
+
+// Sampler is implemented by states that can redeal the information hidden
+// from the current agent. The returned world is a complete state that the
+// current agent could not tell apart from the real one.
+type Sampler interface {
+	State
+	SampleWorld() (State, error)
+}
+
+// SampleChanceOutcome draws one outcome from a chance node
+func SampleChanceOutcome(state State) Action {
+	outcomes, probs := state.ChanceOutcomes()
+	num := rand.Float64()
+	sum := 0.0
+	for i, prob := range probs {
+		sum += prob
+		if sum > num {
+			return outcomes[i]
+		}
+	}
+	// Guard against rounding in the probabilities
+	return outcomes[len(outcomes)-1]
+}
+
+// traversal holds the settings that stay fixed during one CFR pass
+type traversal struct {
+	playerID     int
+	sampleChance bool
+	prune        bool
+	// When leafValue is set, positions where leafTricks tricks have been
+	// played are scored by it instead of being searched
+	leafValue  ValueFunction
+	leafTricks int
+	scratch    *cfrScratch
+}
+
+// cfrScratch is the working memory of one traversal. Each node takes its
+// slices from the top of the stacks and gives them back before it returns,
+// so the stacks only grow when the traversal goes deeper than they reach.
+type cfrScratch struct {
+	floats []float64
+	flags  []bool
+}
+
+// newCFRScratch returns stacks sized for a hand of Euchre, about twenty
+// decisions deep with a handful of actions each
+func newCFRScratch() *cfrScratch {
+	return &cfrScratch{
+		floats: make([]float64, 0, 512),
+		flags:  make([]bool, 0, 128),
+	}
+}
+
+// takeFloats returns n zeroed floats from the top of the stack
+func (scratch *cfrScratch) takeFloats(n int) []float64 {
+	top := len(scratch.floats)
+	for len(scratch.floats) < top+n {
+		scratch.floats = append(scratch.floats, 0)
+	}
+	floats := scratch.floats[top : top+n : top+n]
+	for i := range floats {
+		floats[i] = 0
+	}
+	return floats
+}
+
+// takeFlags returns n cleared flags from the top of the stack
+func (scratch *cfrScratch) takeFlags(n int) []bool {
+	top := len(scratch.flags)
+	for len(scratch.flags) < top+n {
+		scratch.flags = append(scratch.flags, false)
+	}
+	flags := scratch.flags[top : top+n : top+n]
+	for i := range flags {
+		flags[i] = false
+	}
+	return flags
+}
+
+// release gives back the top numFloats floats and numFlags flags
+func (scratch *cfrScratch) release(numFloats int, numFlags int) {
+	scratch.floats = scratch.floats[:len(scratch.floats)-numFloats]
+	scratch.flags = scratch.flags[:len(scratch.flags)-numFlags]
+}
+
+// CFR runs one iteration of vanilla CFR for playerID, enumerating every
+// outcome of the chance nodes it meets. Only practical on small games.
 func (strat *Strategy) CFR(playerID int, state State, agentPathProbs []float64) float64 {
-	return strat.cfr(playerID, state, agentPathProbs, 0)
+	return strat.cfr(traversal{playerID: playerID, scratch: newCFRScratch()}, state, agentPathProbs, 1.0)
+}
+
+// ChanceSamplingCFR runs one iteration of chance-sampling CFR for playerID,
+// following a single sampled outcome at every chance node.
+func (strat *Strategy) ChanceSamplingCFR(playerID int, state State, agentPathProbs []float64) float64 {
+	return strat.cfr(traversal{playerID: playerID, sampleChance: true, scratch: newCFRScratch()}, state, agentPathProbs, 1.0)
 }
-func (strat *Strategy) cfr(playerID int, state State, agentPathProbs []float64, depth int) float64 {
-	currentAgent := state.GetCurrentAgent()
+
+// PruningCFR runs one iteration of chance-sampling CFR for playerID with
+// regret-based pruning. A pruned action has zero probability in the current
+// strategy, so skipping it leaves the node's utility and every cumulative
+// strategy sum unchanged; only the regrets under it go stale until the next
+// revisit iteration.
+func (strat *Strategy) PruningCFR(playerID int, state State, agentPathProbs []float64, iteration int) float64 {
+	revisit := strat.Pruning.RevisitInterval
+	prune := revisit > 0 && iteration%revisit != 0
+	return strat.cfr(traversal{playerID: playerID, sampleChance: true, prune: prune, scratch: newCFRScratch()}, state, agentPathProbs, 1.0)
+}
+
+// DepthLimitedCFR runs one iteration of vanilla CFR for playerID that only
+// looks tricks tricks ahead. Positions at that depth are scored by leaf
+// instead of being played out. A depth below 1 trick would score the root
+// itself and train nothing, so those searches, like states that don't
+// implement TrickCounter, are searched to the end.
+func (strat *Strategy) DepthLimitedCFR(playerID int, state State, agentPathProbs []float64, tricks int, leaf ValueFunction) float64 {
+	t := traversal{playerID: playerID, scratch: newCFRScratch()}
+	if counter, ok := state.(TrickCounter); ok && tricks >= 1 {
+		t.leafValue = leaf
+		t.leafTricks = counter.TricksPlayed() + tricks
+	}
+	return strat.cfr(t, state, agentPathProbs, 1.0)
+}
+
+func (strat *Strategy) cfr(t traversal, state State, agentPathProbs []float64, chanceProb float64) float64 {
+	playerID := t.playerID
 
 	if state.IsTerminal() {
 		return state.GetUtility(playerID)
 	}
 
-	validActions := state.ValidActions()
+	if strat.Endgame != nil {
+		if prober, ok := state.(EndgameProber); ok {
+			if value, found := prober.ProbeEndgame(strat.Endgame, playerID); found {
+				return value
+			}
+		}
+	}
+
+	if t.leafValue != nil {
+		if counter, ok := state.(TrickCounter); ok && counter.TricksPlayed() >= t.leafTricks {
+			return t.leafValue.Value(state, playerID)
+		}
+	}
+
+	if state.IsChanceNode() {
+		if t.sampleChance {
+			outcome := SampleChanceOutcome(state)
+			utility := strat.cfr(t, applyAction(state, outcome), agentPathProbs, chanceProb)
+			undoAction(state, outcome)
+			return utility
+		}
+
+		outcomes, probs := state.ChanceOutcomes()
+		utility := 0.0
+		for i, outcome := range outcomes {
+			utility += probs[i] * strat.cfr(t, applyAction(state, outcome), agentPathProbs, chanceProb*probs[i])
+			undoAction(state, outcome)
+		}
+		return utility
+	}
+
+	currentAgent := state.GetCurrentAgent()
+	validActions := strat.actions(state)
 
 	if len(validActions) == 1 {
-		// Pass the state by reference since we don't need to run other actions
-		return strat.cfr(playerID, state.TakeAction(validActions[0]), agentPathProbs, depth+1)
+		action := strat.concreteAction(state, validActions[0])
+		utility := strat.cfr(t, applyAction(state, action), agentPathProbs, chanceProb)
+		undoAction(state, action)
+		return utility
 	}
 
-	infoSetKey := state.GetInfoSetKey()
-	info, exists := strat.InfoSetMap[infoSetKey]
+	// Read the current strategy under the shard lock, then release it
+	// while the children are traversed
+	infoSetKey := strat.infoSetKey(state)
+	shard := strat.shard(infoSetKey)
+	shard.Lock()
+	info, exists := shard.infoSets[infoSetKey]
 	if !exists {
 		info = makeInfoSet(validActions)
-		strat.InfoSetMap[infoSetKey] = info
+		shard.infoSets[infoSetKey] = info
+	}
+	// The info set's own actions are the ones its slices are aligned with
+	validActions = info.Actions
+	numActions := len(validActions)
+	actionProbs := t.scratch.takeFloats(numActions)
+	pruned := t.scratch.takeFlags(numActions)
+	for i := range validActions {
+		actionProbs[i] = info.CurrentStrategy[i]
+		pruned[i] = t.prune && currentAgent == playerID && actionProbs[i] == 0 &&
+			info.CumulativeRegret[i] < strat.Pruning.Threshold
 	}
+	shard.Unlock()
 
 	utility := 0.0
-	actionUtility := make(map[Action]float64)
-	for _, action := range validActions {
-		actionProb := info.CurrentStrategy[action]
+	actionUtility := t.scratch.takeFloats(numActions)
+	newPathProbs := t.scratch.takeFloats(len(agentPathProbs))
+	copy(newPathProbs, agentPathProbs)
+	defer t.scratch.release(2*numActions+len(agentPathProbs), numActions)
+	for i, action := range validActions {
+		if pruned[i] {
+			continue
+		}
 
-		newPathProbs := make([]float64, len(agentPathProbs))
-		copy(newPathProbs, agentPathProbs)
-		newPathProbs[currentAgent] = newPathProbs[currentAgent] * actionProb
+		newPathProbs[currentAgent] = agentPathProbs[currentAgent] * actionProbs[i]
 
-		actionUtility[action] = strat.cfr(playerID, state.TakeActionCopy(action), newPathProbs, depth+1)
-		utility += (actionProb * actionUtility[action])
+		concrete := strat.concreteAction(state, action)
+		actionUtility[i] = strat.cfr(t, applyAction(state, concrete), newPathProbs, chanceProb)
+		undoAction(state, concrete)
+		utility += (actionProbs[i] * actionUtility[i])
 	}
 
 	if currentAgent == playerID {
 		// Find the probability of attempting to reach the current state
-		nonPlayerPathProb := float64(1.0)
+		nonPlayerPathProb := chanceProb
 		for index, prob := range agentPathProbs {
 			if index != playerID {
 				nonPlayerPathProb *= prob
 			}
 		}
 
-		for _, action := range validActions {
-			info.CumulativeRegret[action] += nonPlayerPathProb * (actionUtility[action] - utility)
-			info.CumulativeStrategySum[action] += agentPathProbs[playerID] * info.CurrentStrategy[action]
+		shard.Lock()
+		for i := range validActions {
+			// Pruned actions have no utility this iteration
+			if !pruned[i] {
+				info.CumulativeRegret[i] += nonPlayerPathProb * (actionUtility[i] - utility)
+			}
+			info.CumulativeStrategySum[i] += agentPathProbs[playerID] * actionProbs[i]
 		}
 
 		info.updateStrategy()
+		shard.Unlock()
 	}
 
 	return utility
@@ -4,10 +4,24 @@ package cfr
 type CFRAgent struct {
 	Strat         *Strategy
 	NumIterations int
+	// LeafValue, if set, limits each search to Depth tricks and scores the
+	// positions there with it. A Depth below 1 searches to the end of the
+	// hand instead.
+	LeafValue ValueFunction
+	Depth     int
+	// Worlds draws the worlds to train on, uniformly when nil
+	Worlds WorldSampler
+	// SampleErr is the last error Worlds gave during the latest Act. The
+	// iterations it failed are skipped.
+	SampleErr error
 }
 
+// ClearStrategy starts a fresh strategy with the same abstraction
 func (agent *CFRAgent) ClearStrategy() {
 	strat := NewStrategy()
+	if agent.Strat != nil {
+		strat.Abstraction = agent.Strat.Abstraction
+	}
 	agent.Strat = &strat
 }
 
@@ -19,21 +33,23 @@ func (agent *CFRAgent) EndGame() {
 func (agent *CFRAgent) Act(state State) Action {
 	var euchreState *EuchreState = state.(*EuchreState)
 
-	if len(euchreState.ValidActions()) == 1 {
-		return euchreState.ValidActions()[0]
-	}
-	// Abstract state
-	oldTrump := euchreState.TrumpSuit
-	euchreState.Normalize(oldTrump)
-	if euchreState.TrumpSuit != SPADES {
-		panic("Trump abstraction failed")
+	// Equivalent cards can leave a single abstract action even when
+	// several cards are valid, and CFR keeps no info set for it
+	actions := agent.Strat.actions(euchreState)
+	if len(actions) == 1 {
+		return agent.Strat.concreteAction(euchreState, actions[0])
 	}
-	key := euchreState.GetInfoSetKey()
+	key := agent.Strat.infoSetKey(euchreState)
 
 	// Train CFR on only this information set
+	agent.SampleErr = nil
 	for i := 0; i < agent.NumIterations; i++ {
 		// Sample another state in the same info set
-		sampledState, _ := euchreState.SampleInfoSet()
+		sampledState, err := sampleWorld(agent.Worlds, euchreState)
+		if err != nil {
+			agent.SampleErr = err
+			continue
+		}
 
 		// Train on it
 		// TODO: Maybe we can ignore training the positions of the other players?
@@ -43,21 +59,22 @@ func (agent *CFRAgent) Act(state State) Action {
 			for p := 0; p < 4; p++ {
 				probs[p] = 1.0
 			}
-			agent.Strat.CFR(playerID, &sampledState, probs)
+			if agent.LeafValue != nil {
+				agent.Strat.DepthLimitedCFR(playerID, sampledState, probs, agent.Depth, agent.LeafValue)
+			} else {
+				agent.Strat.CFR(playerID, sampledState, probs)
+			}
 		}
 	}
 
-	infoSet := agent.Strat.InfoSetMap[key]
-	newStrategy := infoSet.getStateStrategy()
-
-	action := sampleAction(newStrategy)
-
-	// Need to unnormalize the action too
-	euchrePlay := Card(action)
-	euchrePlay.normalizeSuit(oldTrump)
-	action = Action(euchrePlay)
+	newStrategy, exists := agent.Strat.stateStrategy(key)
+	if !exists {
+		// An endgame table can settle the state before CFR reaches an
+		// info set for it, and no world may have been sampled at all
+		return sampleAction(agent.Strat.Policy(euchreState))
+	}
 
-	euchreState.Unnormalize(oldTrump)
+	action := agent.Strat.concreteAction(euchreState, sampleAction(newStrategy))
 
 	//agent.ClearStrategy()
 
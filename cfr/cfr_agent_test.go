@@ -0,0 +1,31 @@
+package cfr
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+// failingSampler never draws a world
+type failingSampler struct{}
+
+func (sampler failingSampler) SampleWorld(state State) (State, error) {
+	return nil, errors.New("No world")
+}
+
+func TestCFRAgentPlaysWhenNoWorldSamples(t *testing.T) {
+	state := playedInto(rand.New(rand.NewSource(1)), 0)
+	agent := CFRAgent{NumIterations: 3, Worlds: failingSampler{}}
+	agent.ClearStrategy()
+	if len(state.ValidActions()) < 2 {
+		t.Fatal("The test state needs a choice to make")
+	}
+
+	action := agent.Act(&state)
+	if !isValid(&state, action) {
+		t.Errorf("Played %v, which isn't valid", action)
+	}
+	if agent.SampleErr == nil {
+		t.Error("The sampling error wasn't reported")
+	}
+}
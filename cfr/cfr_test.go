@@ -0,0 +1,109 @@
+package cfr
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// copiedEuchre hides UndoAction, so searches copy it at every node
+type copiedEuchre struct {
+	*EuchreState
+}
+
+func (state copiedEuchre) TakeAction(action Action) State {
+	state.EuchreState.TakeAction(action)
+	return state
+}
+
+func (state copiedEuchre) TakeActionCopy(action Action) State {
+	clone := state.Clone()
+	clone.TakeAction(action)
+	return copiedEuchre{&clone}
+}
+
+func TestInPlaceMinimaxMatchesCopying(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	for trial := 0; trial < 100; trial++ {
+		state := playedInto(r, 8+r.Intn(4))
+		if state.IsTerminal() {
+			continue
+		}
+		before := state
+		inPlace, _ := minimax(&state, state.currentAgent, nil)
+		if state != before {
+			t.Fatal("Searching in place didn't restore the state")
+		}
+
+		clone := state.Clone()
+		copied, _ := minimax(copiedEuchre{&clone}, state.currentAgent, nil)
+		if inPlace != copied {
+			t.Fatalf("In place search scores %v, copying search %v", inPlace, copied)
+		}
+	}
+}
+
+func TestInPlaceCFRMatchesCopying(t *testing.T) {
+	var deck []Card
+	for _, suit := range []Suit{HEARTS, SPADES} {
+		for value := NINE; value <= ACE; value++ {
+			deck = append(deck, makeCard(suit, value))
+		}
+	}
+
+	root := NewEuchreVariant(deck, 2, 0, 0)
+	before := root
+	copiedRoot := root
+	inPlace, copied := NewStrategy(), NewStrategy()
+	var inPlaceUtility, copiedUtility float64
+	for i := 0; i < 200; i++ {
+		for playerID := 0; playerID < 4; playerID++ {
+			// Both traversals sample the same deal
+			rand.Seed(int64(4*i + playerID))
+			inPlaceUtility += inPlace.ChanceSamplingCFR(playerID, &root, []float64{1, 1, 1, 1})
+			rand.Seed(int64(4*i + playerID))
+			copiedUtility += copied.ChanceSamplingCFR(playerID, copiedEuchre{&copiedRoot}, []float64{1, 1, 1, 1})
+		}
+	}
+
+	if root != before || copiedRoot != before {
+		t.Fatal("Training didn't restore the root")
+	}
+	if inPlace.Len() == 0 {
+		t.Fatal("No info sets were trained")
+	}
+	if math.Abs(inPlaceUtility-copiedUtility) > 1e-9 || inPlace.Len() != copied.Len() {
+		t.Fatalf("In place CFR found utility %v over %d info sets, copying CFR %v over %d",
+			inPlaceUtility, inPlace.Len(), copiedUtility, copied.Len())
+	}
+}
+
+func TestDepthLimitedCFRBelowOneTrickSearchesToTheEnd(t *testing.T) {
+	var deck []Card
+	for _, suit := range []Suit{HEARTS, SPADES} {
+		for value := NINE; value <= ACE; value++ {
+			deck = append(deck, makeCard(suit, value))
+		}
+	}
+	state := NewEuchreVariant(deck, 2, 0, 0)
+	r := rand.New(rand.NewSource(1))
+	for state.IsChanceNode() {
+		outcomes, _ := state.ChanceOutcomes()
+		state.TakeAction(outcomes[r.Intn(len(outcomes))])
+	}
+
+	for _, depth := range []int{0, -1} {
+		limited, full := NewStrategy(), NewStrategy()
+		for playerID := 0; playerID < 4; playerID++ {
+			leaf := RolloutValue{NumRollouts: 1}
+			limitedUtility := limited.DepthLimitedCFR(playerID, &state, []float64{1, 1, 1, 1}, depth, leaf)
+			fullUtility := full.CFR(playerID, &state, []float64{1, 1, 1, 1})
+			if limitedUtility != fullUtility {
+				t.Fatalf("Depth %d found utility %v, the full search %v", depth, limitedUtility, fullUtility)
+			}
+		}
+		if limited.Len() == 0 || limited.Len() != full.Len() {
+			t.Errorf("Depth %d trained %d info sets, the full search %d", depth, limited.Len(), full.Len())
+		}
+	}
+}
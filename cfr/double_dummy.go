@@ -0,0 +1,285 @@
+package cfr
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Deal is a fully specified Euchre hand for double dummy analysis
+type Deal struct {
+	// Hands of seats 0-3, all the same size
+	Hands [4][]Card
+	Trump Suit
+	// Maker is the seat that called trump
+	Maker int
+	// Leader is the seat that leads the first trick
+	Leader int
+}
+
+// DoubleDummyResult is the outcome of a deal under perfect play
+type DoubleDummyResult struct {
+	// Tricks taken by team 0 (seats 0 and 2) and team 1 (seats 1 and 3)
+	Tricks [2]int
+	// MakerPoints is what the makers score: 1 for making it, 2 for taking
+	// every trick and -2 for being euchred
+	MakerPoints int
+}
+
+// DoubleDummySolver finds how many tricks each team takes when every card
+// is visible and both teams play to take as many tricks as possible. Since
+// Euchre scoring only improves with more tricks, that play is also optimal
+// for points. Positions are memoized across calls, so analyzing many
+// points of the same deal is cheap.
+type DoubleDummySolver struct {
+	deal  Deal
+	hands [4]uint32
+	// Cards of each effective suit, indexed by suit/10 - 1
+	suitMasks [4]uint32
+	// Rank of each card when a suit is led, indexed like suitMasks
+	ranks [4][24]int
+	// Cards of each effective suit from lowest to highest
+	suitOrder [4][]int
+	memo      map[ddPosition]int
+}
+
+// ddTrick is the trick in progress
+type ddTrick struct {
+	leader int
+	count  int
+	cards  [4]int
+}
+
+type ddPosition struct {
+	hands [4]uint32
+	trick ddTrick
+}
+
+// NewDoubleDummySolver checks the deal and builds a solver for it
+func NewDoubleDummySolver(deal Deal) (*DoubleDummySolver, error) {
+	if deal.Trump != DIAMONDS && deal.Trump != HEARTS && deal.Trump != SPADES && deal.Trump != CLUBS {
+		return nil, errors.New("Trump is not a valid suit")
+	}
+	if deal.Maker < 0 || deal.Maker > 3 || deal.Leader < 0 || deal.Leader > 3 {
+		return nil, errors.New("Maker and leader must be seats 0-3")
+	}
+
+	solver := DoubleDummySolver{
+		deal: deal,
+		memo: make(map[ddPosition]int),
+	}
+	var seen uint32
+	for seat, hand := range deal.Hands {
+		if len(hand) == 0 || len(hand) > 5 || len(hand) != len(deal.Hands[0]) {
+			return nil, errors.New("Hands must all hold the same number of cards, at most 5")
+		}
+		for _, card := range hand {
+			if card.getSuit() < DIAMONDS || card.getSuit() > CLUBS || card.getValue() < NINE || card.getValue() > ACE {
+				return nil, fmt.Errorf("%d is not a valid card", card)
+			}
+			bit := uint32(1) << uint(card.index())
+			if seen&bit != 0 {
+				return nil, fmt.Errorf("%s is dealt twice", card.ToString())
+			}
+			seen |= bit
+			solver.hands[seat] |= bit
+		}
+	}
+
+	for s := 10; s <= 40; s += 10 {
+		leadSuit := Suit(s)
+		rankings := getRankings(deal.Trump, leadSuit)
+		for _, card := range rankings {
+			solver.ranks[s/10-1][card.index()] = getRankTable(deal.Trump, leadSuit).rank(card)
+			if card.effectiveSuit(deal.Trump) == leadSuit {
+				solver.suitMasks[s/10-1] |= 1 << uint(card.index())
+				solver.suitOrder[s/10-1] = append(solver.suitOrder[s/10-1], card.index())
+			}
+		}
+	}
+
+	return &solver, nil
+}
+
+// Solve plays the whole deal out with perfect play
+func (solver *DoubleDummySolver) Solve() DoubleDummyResult {
+	team0 := solver.tricks(solver.hands, ddTrick{leader: solver.deal.Leader})
+	tricks := [2]int{team0, len(solver.deal.Hands[0]) - team0}
+
+	return DoubleDummyResult{
+		Tricks:      tricks,
+		MakerPoints: solver.makerPoints(tricks),
+	}
+}
+
+// CardValues replays the cards played so far, in order, and returns every
+// legal card of the player to act along with the tricks that player's team
+// takes over the whole hand if they play it and everyone plays perfectly
+// afterwards.
+func (solver *DoubleDummySolver) CardValues(played []Card) (map[Card]int, error) {
+	hands := solver.hands
+	trick := ddTrick{leader: solver.deal.Leader}
+	taken := [2]int{0, 0}
+	for _, card := range played {
+		seat := (trick.leader + trick.count) % 4
+		bit := uint32(1) << uint(card.index())
+		if hands[seat]&bit == 0 {
+			return nil, fmt.Errorf("Seat %d does not hold the %s", seat, card.ToString())
+		}
+		if solver.legalCards(hands[seat], trick)&bit == 0 {
+			return nil, fmt.Errorf("Seat %d must follow suit instead of playing the %s", seat, card.ToString())
+		}
+
+		var winner int
+		hands, trick, winner = solver.play(hands, trick, card.index())
+		if winner != -1 {
+			taken[winner%2]++
+		}
+	}
+	if hands[0]|hands[1]|hands[2]|hands[3] == 0 {
+		return nil, errors.New("Every card has been played")
+	}
+
+	seat := (trick.leader + trick.count) % 4
+	team := seat % 2
+	values := make(map[Card]int)
+	legal := solver.legalCards(hands[seat], trick)
+	for idx := 0; idx < 24; idx++ {
+		if legal&(1<<uint(idx)) == 0 {
+			continue
+		}
+		nextHands, nextTrick, winner := solver.play(hands, trick, idx)
+		team0 := taken[0] + solver.tricks(nextHands, nextTrick)
+		if winner == 0 || winner == 2 {
+			team0++
+		}
+
+		value := team0
+		if team == 1 {
+			value = len(solver.deal.Hands[0]) - team0
+		}
+		values[cardFromIndex(idx)] = value
+	}
+
+	return values, nil
+}
+
+// tricks returns how many of the remaining tricks team 0 takes, with team 0
+// maximizing and team 1 minimizing
+func (solver *DoubleDummySolver) tricks(hands [4]uint32, trick ddTrick) int {
+	if hands[0]|hands[1]|hands[2]|hands[3] == 0 {
+		return 0
+	}
+
+	position := ddPosition{hands: hands, trick: trick}
+	if value, exists := solver.memo[position]; exists {
+		return value
+	}
+
+	seat := (trick.leader + trick.count) % 4
+	legal := solver.distinctCards(hands, trick, solver.legalCards(hands[seat], trick))
+	best := -1
+	for idx := 0; idx < 24; idx++ {
+		if legal&(1<<uint(idx)) == 0 {
+			continue
+		}
+		nextHands, nextTrick, winner := solver.play(hands, trick, idx)
+		value := solver.tricks(nextHands, nextTrick)
+		if winner == 0 || winner == 2 {
+			value++
+		}
+		if best == -1 || (seat%2 == 0 && value > best) || (seat%2 == 1 && value < best) {
+			best = value
+		}
+	}
+
+	solver.memo[position] = best
+	return best
+}
+
+// legalCards returns the cards in hand that may be played to the trick
+func (solver *DoubleDummySolver) legalCards(hand uint32, trick ddTrick) uint32 {
+	if trick.count == 0 {
+		return hand
+	}
+	leadSuit := solver.effectiveSuitIdx(trick.cards[0])
+	if follow := hand & solver.suitMasks[leadSuit]; follow != 0 {
+		return follow
+	}
+	return hand
+}
+
+// distinctCards drops cards that are interchangeable with a higher card of
+// the same suit in the same hand. Once played cards are gone, nothing
+// separates the two, so playing either leads to the same result.
+func (solver *DoubleDummySolver) distinctCards(hands [4]uint32, trick ddTrick, legal uint32) uint32 {
+	live := hands[0] | hands[1] | hands[2] | hands[3]
+	for i := 0; i < trick.count; i++ {
+		live |= 1 << uint(trick.cards[i])
+	}
+
+	for _, order := range solver.suitOrder {
+		previous := -1
+		for _, idx := range order {
+			bit := uint32(1) << uint(idx)
+			if live&bit == 0 {
+				continue
+			}
+			if previous != -1 && legal&bit != 0 {
+				legal &^= 1 << uint(previous)
+			}
+			if legal&bit != 0 {
+				previous = idx
+			} else {
+				previous = -1
+			}
+		}
+	}
+	return legal
+}
+
+// play puts a card on the trick. When it completes the trick the winning
+// seat is returned and leads the next one, otherwise the winner is -1.
+func (solver *DoubleDummySolver) play(hands [4]uint32, trick ddTrick, idx int) ([4]uint32, ddTrick, int) {
+	seat := (trick.leader + trick.count) % 4
+	hands[seat] &^= 1 << uint(idx)
+	trick.cards[trick.count] = idx
+	trick.count++
+	if trick.count < 4 {
+		return hands, trick, -1
+	}
+
+	leadSuit := solver.effectiveSuitIdx(trick.cards[0])
+	bestIdx := 0
+	for i := 1; i < 4; i++ {
+		if solver.ranks[leadSuit][trick.cards[i]] > solver.ranks[leadSuit][trick.cards[bestIdx]] {
+			bestIdx = i
+		}
+	}
+	winner := (trick.leader + bestIdx) % 4
+	return hands, ddTrick{leader: winner}, winner
+}
+
+func (solver *DoubleDummySolver) effectiveSuitIdx(idx int) int {
+	for s, mask := range solver.suitMasks {
+		if mask&(1<<uint(idx)) != 0 {
+			return s
+		}
+	}
+	panic("Card has no effective suit")
+}
+
+// makerPoints scores the hand for the makers the same way EuchreState does
+func (solver *DoubleDummySolver) makerPoints(tricks [2]int) int {
+	makers := solver.deal.Maker % 2
+	if tricks[1-makers] > tricks[makers] {
+		return -2
+	} else if tricks[makers] == len(solver.deal.Hands[0]) {
+		return 2
+	}
+	return 1
+}
+
+// cardFromIndex is the inverse of Card.index
+func cardFromIndex(idx int) Card {
+	return makeCard(Suit((idx/6+1)*10), Value(idx%6+1))
+}
@@ -0,0 +1,93 @@
+package cfr
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestDoubleDummyKnownDeals(t *testing.T) {
+	tests := []struct {
+		name   string
+		deal   Deal
+		tricks [2]int
+		points int
+	}{
+		{
+			name: "trump ace beats the trump king and nine",
+			deal: Deal{
+				Hands: [4][]Card{
+					{makeCard(SPADES, ACE)},
+					{makeCard(SPADES, NINE)},
+					{makeCard(HEARTS, NINE)},
+					{makeCard(SPADES, KING)},
+				},
+				Trump: SPADES,
+			},
+			tricks: [2]int{1, 0},
+			points: 2,
+		},
+		{
+			name: "right bower draws the left and the ace cashes",
+			deal: Deal{
+				Hands: [4][]Card{
+					{makeCard(HEARTS, JACK), makeCard(CLUBS, ACE)},
+					{makeCard(DIAMONDS, JACK), makeCard(CLUBS, NINE)},
+					{makeCard(SPADES, NINE), makeCard(SPADES, TEN)},
+					{makeCard(CLUBS, KING), makeCard(CLUBS, QUEEN)},
+				},
+				Trump: HEARTS,
+				Maker: 1,
+			},
+			tricks: [2]int{2, 0},
+			points: -2,
+		},
+		{
+			name: "the left bower ruffs an ace but must not follow clubs",
+			deal: Deal{
+				Hands: [4][]Card{
+					{makeCard(DIAMONDS, ACE), makeCard(CLUBS, ACE)},
+					{makeCard(CLUBS, JACK), makeCard(CLUBS, TEN)},
+					{makeCard(DIAMONDS, NINE), makeCard(HEARTS, NINE)},
+					{makeCard(HEARTS, TEN), makeCard(HEARTS, QUEEN)},
+				},
+				Trump:  SPADES,
+				Maker:  1,
+				Leader: 0,
+			},
+			tricks: [2]int{1, 1},
+			points: 1,
+		},
+	}
+
+	for _, test := range tests {
+		solver, err := NewDoubleDummySolver(test.deal)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		result := solver.Solve()
+		if result.Tricks != test.tricks || result.MakerPoints != test.points {
+			t.Errorf("%s: got tricks %v and %d points, expected tricks %v and %d points",
+				test.name, result.Tricks, result.MakerPoints, test.tricks, test.points)
+		}
+	}
+}
+
+// The solver and minimax over EuchreState decide tricks with separate code,
+// so they check each other
+func TestDoubleDummyMatchesMinimax(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for d := 0; d < 200; d++ {
+		state := dealHand(r, 3)
+		deal := state.Deal()
+
+		solver, err := NewDoubleDummySolver(deal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		points := solver.Solve().MakerPoints
+		value, _ := minimax(&state, deal.Maker, nil)
+		if float64(points) != value {
+			t.Fatalf("Deal %v: solver scores %d for the makers, minimax %v", deal, points, value)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package cfr
+
+import (
+	"encoding/gob"
+	"io"
+	"sync"
+)
+
+// EndgameKey identifies a position at the start of a trick late in a hand.
+// Trump is normalized away and every card is replaced by its rank among the
+// cards of its suit still in the hands, since only the relative order of
+// cards decides who wins a trick. Seats are counted from the leader, and
+// each seat has one byte of ranks per suit: trump, the other suit of the
+// same colour, then the remaining two. Those two play alike, so they are
+// put in a canonical order and mirror image positions share a key.
+type EndgameKey [4]uint32
+
+// EndgameTable stores how many of the remaining tricks the leader's team
+// takes under double dummy play. Positions are solved when first looked up,
+// or ahead of time with Generate. It is safe for concurrent use.
+type EndgameTable struct {
+	// MaxCards is the most cards per hand a position can hold and still be
+	// looked up
+	MaxCards int
+
+	lock   sync.RWMutex
+	values map[EndgameKey]int8
+}
+
+// EndgameProber is implemented by states that can find their value in an
+// endgame table
+type EndgameProber interface {
+	// ProbeEndgame returns the value of the state for playerID when the
+	// table covers it
+	ProbeEndgame(table *EndgameTable, playerID int) (float64, bool)
+}
+
+// endgameCapacity is the number of cards in each suit of an EndgameKey
+var endgameCapacity = [4]int{7, 5, 6, 6}
+
+// NewEndgameTable returns an empty table for positions with at most
+// maxCards cards per hand
+func NewEndgameTable(maxCards int) *EndgameTable {
+	return &EndgameTable{
+		MaxCards: maxCards,
+		values:   make(map[EndgameKey]int8),
+	}
+}
+
+// Lookup returns the number of remaining tricks the leader's team takes,
+// solving the position first if it isn't in the table yet
+func (table *EndgameTable) Lookup(key EndgameKey) (int, error) {
+	table.lock.RLock()
+	tricks, exists := table.values[key]
+	table.lock.RUnlock()
+	if exists {
+		return int(tricks), nil
+	}
+
+	solved, err := key.solve()
+	if err != nil {
+		return 0, err
+	}
+	table.lock.Lock()
+	table.values[key] = int8(solved)
+	table.lock.Unlock()
+	return solved, nil
+}
+
+// Generate solves every position with cardsPerHand cards in each hand and
+// returns how many there are. Two cards per hand gives a few hundred
+// thousand positions, three gives far too many to hold, so larger tables
+// are better filled by Lookup as positions come up.
+func (table *EndgameTable) Generate(cardsPerHand int) (int, error) {
+	count := 0
+	var err error
+	var counts [4]int
+	var fillSuit func(slot int, left int)
+	fillSuit = func(slot int, left int) {
+		if slot == 3 {
+			if left > endgameCapacity[slot] {
+				return
+			}
+			counts[slot] = left
+			remaining := [4]int{cardsPerHand, cardsPerHand, cardsPerHand, cardsPerHand}
+			table.generateLayouts(counts, 0, 0, remaining, EndgameKey{}, &count, &err)
+			return
+		}
+		for n := 0; n <= endgameCapacity[slot] && n <= left && err == nil; n++ {
+			counts[slot] = n
+			fillSuit(slot+1, left-n)
+		}
+	}
+	fillSuit(0, 4*cardsPerHand)
+	return count, err
+}
+
+// generateLayouts deals every rank of every suit to each seat with room
+// left for it, solving each canonical key it reaches
+func (table *EndgameTable) generateLayouts(counts [4]int, slot int, rank int, remaining [4]int, key EndgameKey, count *int, err *error) {
+	if *err != nil {
+		return
+	}
+	if slot == 4 {
+		if key == key.canonical() {
+			_, *err = table.Lookup(key)
+			*count++
+		}
+		return
+	}
+	if rank == counts[slot] {
+		table.generateLayouts(counts, slot+1, 0, remaining, key, count, err)
+		return
+	}
+
+	for seat := 0; seat < 4; seat++ {
+		if remaining[seat] == 0 {
+			continue
+		}
+		next := key
+		next[seat] |= 1 << uint(slot*8+rank)
+		remaining[seat]--
+		table.generateLayouts(counts, slot, rank+1, remaining, next, count, err)
+		remaining[seat]++
+	}
+}
+
+// Len returns the number of positions in the table
+func (table *EndgameTable) Len() int {
+	table.lock.RLock()
+	defer table.lock.RUnlock()
+	return len(table.values)
+}
+
+// Save writes the solved positions to w
+func (table *EndgameTable) Save(w io.Writer) error {
+	table.lock.RLock()
+	defer table.lock.RUnlock()
+	return gob.NewEncoder(w).Encode(table.values)
+}
+
+// Load adds the positions saved by Save to the table
+func (table *EndgameTable) Load(r io.Reader) error {
+	values := make(map[EndgameKey]int8)
+	if err := gob.NewDecoder(r).Decode(&values); err != nil {
+		return err
+	}
+
+	table.lock.Lock()
+	defer table.lock.Unlock()
+	for key, tricks := range values {
+		table.values[key] = tricks
+	}
+	return nil
+}
+
+// endgameOrder holds, for each trump suit, the card indices of every suit
+// of an EndgameKey from lowest to highest
+var endgameOrder = makeEndgameOrder()
+
+func makeEndgameOrder() [4][4][]int {
+	var order [4][4][]int
+	for t := 10; t <= 40; t += 10 {
+		trumpSuit := Suit(t)
+		suits := [4]Suit{trumpSuit, trumpSuit.complement()}
+		slot := 2
+		for s := 10; s <= 40; s += 10 {
+			if Suit(s) != suits[0] && Suit(s) != suits[1] {
+				suits[slot] = Suit(s)
+				slot++
+			}
+		}
+
+		for slot, suit := range suits {
+			for _, card := range getRankings(trumpSuit, suit) {
+				if card.effectiveSuit(trumpSuit) == suit {
+					order[t/10-1][slot] = append(order[t/10-1][slot], card.index())
+				}
+			}
+		}
+	}
+	return order
+}
+
+// makeEndgameKey canonicalizes the hands of a position where leader is
+// about to lead
+func makeEndgameKey(hands [4]CardSet, leader int, trumpSuit Suit) EndgameKey {
+	owners := [24]int{}
+	for i := range owners {
+		owners[i] = -1
+	}
+	for seat, hand := range hands {
+		for _, card := range hand.Cards() {
+			owners[card.index()] = (seat - leader + 4) % 4
+		}
+	}
+
+	var key EndgameKey
+	for slot, order := range endgameOrder[trumpSuit/10-1] {
+		rank := 0
+		for _, idx := range order {
+			if seat := owners[idx]; seat != -1 {
+				key[seat] |= 1 << uint(slot*8+rank)
+				rank++
+			}
+		}
+	}
+	return key.canonical()
+}
+
+// canonical orders the two suits of the other colour from trump by their
+// ranks in each seat. Neither can be trump, and no suit has been led at
+// the start of a trick, so swapping them doesn't change the play.
+func (key EndgameKey) canonical() EndgameKey {
+	if key.suitColumn(3) >= key.suitColumn(2) {
+		return key
+	}
+	for seat := range key {
+		low := key[seat] >> 16 & 0xff
+		high := key[seat] >> 24 & 0xff
+		key[seat] = key[seat]&0xffff | high<<16 | low<<24
+	}
+	return key
+}
+
+// suitColumn gathers the byte of every seat for the suit in slot
+func (key EndgameKey) suitColumn(slot int) uint32 {
+	var column uint32
+	for seat := range key {
+		column |= (key[seat] >> uint(slot*8) & 0xff) << uint(seat*8)
+	}
+	return column
+}
+
+// solve builds a deal for the key with spades as trump and solves it
+func (key EndgameKey) solve() (int, error) {
+	deal := Deal{Trump: SPADES}
+	for slot, order := range endgameOrder[SPADES/10-1] {
+		for rank := 0; rank < 8; rank++ {
+			for seat := 0; seat < 4; seat++ {
+				if key[seat]&(1<<uint(slot*8+rank)) != 0 {
+					deal.Hands[seat] = append(deal.Hands[seat], cardFromIndex(order[rank]))
+				}
+			}
+		}
+	}
+
+	solver, err := NewDoubleDummySolver(deal)
+	if err != nil {
+		return 0, err
+	}
+	return solver.Solve().Tricks[0], nil
+}
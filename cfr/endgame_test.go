@@ -0,0 +1,81 @@
+package cfr
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// copyHands copies the hands of state so a solver can't share them
+func copyHands(state EuchreState) [4][]Card {
+	var hands [4][]Card
+	for seat, hand := range state.playerHands {
+		hands[seat] = hand.Cards()
+	}
+	return hands
+}
+
+// The table and the solver should agree on every position the table keys
+func TestEndgameTableMatchesDoubleDummy(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	table := NewEndgameTable(3)
+	for _, numPlayed := range []int{8, 12, 16} {
+		for d := 0; d < 100; d++ {
+			state := playedInto(r, numPlayed)
+			if state.IsTerminal() {
+				continue
+			}
+			if state.numPlayed%4 != 0 {
+				t.Fatalf("%d random cards should end at the start of a trick", numPlayed)
+			}
+
+			deal := Deal{Hands: copyHands(state), Trump: state.TrumpSuit, Leader: state.lead}
+			solver, err := NewDoubleDummySolver(deal)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := solver.Solve().Tricks[state.lead%2]
+
+			tricks, err := table.Lookup(makeEndgameKey(state.playerHands, state.lead, state.TrumpSuit))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tricks != expected {
+				t.Fatalf("Deal %v: table gives the leader %d tricks, the solver %d", deal, tricks, expected)
+			}
+		}
+	}
+}
+
+// Swapping the two suits of the other colour from trump gives the same key
+func TestEndgameKeyIgnoresOtherColourOrder(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for d := 0; d < 200; d++ {
+		state := playedInto(r, 4*r.Intn(4))
+		first, second := DIAMONDS, HEARTS
+		if state.TrumpSuit == DIAMONDS || state.TrumpSuit == HEARTS {
+			first, second = SPADES, CLUBS
+		}
+
+		swapped := state.playerHands
+		for seat := range swapped {
+			swapped[seat] = swapped[seat].relabel(func(s Suit) Suit {
+				switch s {
+				case first:
+					return second
+				case second:
+					return first
+				}
+				return s
+			})
+		}
+
+		key := makeEndgameKey(state.playerHands, state.lead, state.TrumpSuit)
+		if other := makeEndgameKey(swapped, state.lead, state.TrumpSuit); other != key {
+			t.Fatalf("Hands %v and %v with trump %v have keys %v and %v",
+				state.playerHands, swapped, state.TrumpSuit, key, other)
+		}
+		if key != key.canonical() {
+			t.Fatalf("Key %v isn't canonical", key)
+		}
+	}
+}
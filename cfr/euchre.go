@@ -1,7 +1,6 @@
 package cfr
 
 import (
-	"errors"
 	"fmt"
 	"math/rand"
 	"sort"
@@ -44,11 +43,11 @@ const (
 	PLAY_TC = EuchreAction(int(CLUBS) + int(TEN))
 	PLAY_NC = EuchreAction(int(CLUBS) + int(NINE))
 	// Bidding actions
-	// PASS_BID      = uint8(7)
-	// CALL_DIAMONDS = DIAMONDS
-	// CALL_HEARTS   = HEARTS
-	// CALL_SPADES   = SPADES
-	// CALL_CLUBS    = CLUBS
+	PASS_BID      = EuchreAction(7)
+	CALL_DIAMONDS = EuchreAction(DIAMONDS)
+	CALL_HEARTS   = EuchreAction(HEARTS)
+	CALL_SPADES   = EuchreAction(SPADES)
+	CALL_CLUBS    = EuchreAction(CLUBS)
 	// Discard Actions
 	// DISCARD_0 = uint8(0)
 	// DISCARD_1 = uint8(1)
@@ -58,232 +57,244 @@ const (
 	// DISCARD_5 = uint8(5)
 )
 
+// InfoSetKeyMode selects how much of the play EuchreState info set keys
+// remember
+type InfoSetKeyMode int
+
+const (
+	// COMPACT_KEYS keep the played cards as a sorted set, along with the
+	// current trick and the voids. Decision points reached by playing the
+	// same cards in a different order share a key.
+	COMPACT_KEYS = InfoSetKeyMode(iota)
+	// PERFECT_RECALL_KEYS keep every card played, in order, with the seat
+	// that played it
+	PERFECT_RECALL_KEYS
+)
+
 // EuchreState stores the current game state of a euchre hand
 type EuchreState struct {
-	playerHands [4][]Card
-	shortSuited [4][]Suit
-	table       []Card
-	history     []Card
-	kitty       []Card
-	teamTricks  [2]int
+	playerHands [4]CardSet
+	// voids holds the cards each seat has shown it can't hold by not
+	// following suit
+	voids [4]CardSet
+	// showedOut has a bit for each play, by its place in the history, that
+	// added a void, so UndoAction can take the void back
+	showedOut uint32
+	// history holds the cards played in order. The current trick is its
+	// last numPlayed % 4 cards.
+	history   [20]Card
+	numPlayed int
+	played    CardSet
+	upcard    Card
+	// kitty holds the cards face down under the upcard
+	kitty      CardSet
+	deck       CardSet
+	teamTricks [2]int
+	// The suit named by each bid in turn from the first lead, or 0 for a
+	// pass. bidding is set until trump is called.
+	bids    [8]Suit
+	numBids int
+	bidding bool
 
 	leadSuit     Suit
 	TrumpSuit    Suit
 	lead         int
+	firstLead    int
 	callingTeam  int
 	currentAgent int
+	handSize     int
+
+	KeyMode InfoSetKeyMode
 }
 
-// NewEuchreState ...
+// NewEuchreState returns a freshly dealt hand
 func NewEuchreState() EuchreState {
 	rand.Seed(time.Now().UnixNano())
+	state := NewEuchreDeal()
+	for state.IsChanceNode() {
+		state.TakeAction(SampleChanceOutcome(&state))
+	}
+
+	return state
+}
+
+// NewEuchreDeal returns a hand before any cards have been dealt. The deal
+// is part of the game tree: each player's hand is dealt one card at a time
+// by chance actions, then the upcard is turned from the remaining cards.
+func NewEuchreDeal() EuchreState {
 	var deck []Card
-	for value := 1; value < 7; value++ {
-		for suit := 10; suit <= 40; suit += 10 {
+	for suit := 10; suit <= 40; suit += 10 {
+		for value := 1; value < 7; value++ {
 			deck = append(deck, Card(suit+value))
 		}
 	}
-	deck = shuffle(deck)
 
-	leadPlayer := rand.Intn(4)
-	state := EuchreState{
+	return NewEuchreVariant(deck, 5, rand.Intn(4), rand.Intn(2))
+}
+
+// NewEuchreVariant returns an undealt hand played with a reduced deck and
+// hand size. Small variants are useful for exact solving and evaluation.
+// The deck must hold at least one card more than the four hands.
+func NewEuchreVariant(deck []Card, handSize int, leadPlayer int, callingTeam int) EuchreState {
+	if len(deck) <= 4*handSize {
+		panic("Deck is too small to deal the hands and the upcard")
+	}
+
+	return EuchreState{
 		lead:         leadPlayer,
-		callingTeam:  rand.Intn(2),
+		firstLead:    leadPlayer,
+		callingTeam:  callingTeam,
 		currentAgent: leadPlayer,
-		teamTricks:   [2]int{0, 0},
-		table:        make([]Card, 0, 4),
-		history:      make([]Card, 0, 24),
-		kitty:        make([]Card, 0, 4),
-	}
-
-	// Deal cards
-	for i := 0; i < 4; i++ {
-		state.shortSuited[i] = make([]Suit, 0)
-		state.playerHands[i] = make([]Card, 5)
-		for c := 0; c < 5; c++ {
-			state.playerHands[i][c] = deck[c+i*5]
-		}
-		// Sort hands
-		sort.Slice(state.playerHands[i], func(j, k int) bool {
-			return state.playerHands[i][j] < state.playerHands[i][k]
-		})
+		handSize:     handSize,
+		deck:         NewCardSet(deck...),
 	}
-	state.kitty = append(state.kitty, deck[20:]...)
-	state.TrumpSuit = state.kitty[0].getSuit()
-
-	return state
 }
 
-func (state EuchreState) SampleInfoSet() (EuchreState, error) {
-	key := state.GetInfoSetKey()
-	newState := state.Clone()
+// IsChanceNode ...
+func (state *EuchreState) IsChanceNode() bool {
+	return !state.deck.Empty()
+}
 
-	// Collect unknown cards
-	intermediateDeck := make([]Card, 0)
+// dealingSeat returns the first hand still owed cards, or -1 once every
+// hand is full and only the upcard remains to be turned
+func (state *EuchreState) dealingSeat() int {
 	for i, hand := range state.playerHands {
-		if i != newState.currentAgent {
-			intermediateDeck = append(intermediateDeck, hand...)
-			newState.playerHands[i] = make([]Card, 0, len(hand))
+		if hand.Len() < state.handSize {
+			return i
 		}
 	}
-	intermediateDeck = append(intermediateDeck, newState.kitty[1:]...)
+	return -1
+}
 
-	intermediateDeck = shuffle(intermediateDeck)
+// ChanceOutcomes ...
+func (state *EuchreState) ChanceOutcomes() ([]Action, []float64) {
+	deck := state.deck.Cards()
+	outcomes := make([]Action, 0, len(deck))
+	probs := make([]float64, 0, len(deck))
 
-	// Need to deal cards that can only fit in one hand to that person
-	// Count number of compatible cards per hand
-	type pair struct {
-		count int
-		index int
-	}
-	counts := []pair{{0, 0}, {0, 1}, {0, 2}, {0, 3}}
-	for handIdx := 0; handIdx < 4; handIdx++ {
-		for _, card := range intermediateDeck {
-			if !inSlice(newState.shortSuited[handIdx], card.effectiveSuit(newState.TrumpSuit)) {
-				counts[handIdx].count++
-			}
+	seat := state.dealingSeat()
+	if seat == -1 {
+		for _, card := range deck {
+			outcomes = append(outcomes, Action(card))
+			probs = append(probs, 1.0/float64(len(deck)))
 		}
+		return outcomes, probs
 	}
-	sort.Slice(counts, func(j, k int) bool {
-		return counts[j].count < counts[k].count
-	})
-	// Deal from most constrained to least
-	for _, countPair := range counts {
-		handIdx := countPair.index
-		suits := newState.shortSuited[handIdx]
-		if handIdx == newState.currentAgent {
-			continue
-		}
-		// Advance through the shuffled cards until a valid card is found
-		for j := 0; len(newState.playerHands[handIdx]) < cap(newState.playerHands[handIdx]); j++ {
-			if j >= len(intermediateDeck) {
-				return EuchreState{}, errors.New("Invalid shuffle")
-			}
-			deckCard := intermediateDeck[j]
-			if deckCard != 0 && !inSlice(suits, deckCard.effectiveSuit(newState.TrumpSuit)) {
-				newState.playerHands[handIdx] = append(newState.playerHands[handIdx], deckCard)
-				intermediateDeck[j] = 0
-			}
-		}
-		// Keep the hands sorted
-		sort.Slice(newState.playerHands[handIdx], func(j, k int) bool {
-			return newState.playerHands[handIdx][j] < newState.playerHands[handIdx][k]
-		})
 
-		// Need to reshuffle to unbias the next hands deal
-		intermediateDeck = shuffle(intermediateDeck)
+	// Hands are dealt in ascending order, so each outcome is the lowest
+	// card the hand will still receive. The rest of the hand must come
+	// from the cards above it, which weights every outcome by how many
+	// ways the remainder of the hand can be filled.
+	hand := state.playerHands[seat]
+	lastCard := Card(0)
+	if !hand.Empty() {
+		lastCard = hand.Highest()
+	}
+	eligible := make([]Card, 0, len(deck))
+	for _, card := range deck {
+		if card > lastCard {
+			eligible = append(eligible, card)
+		}
 	}
 
-	newState.kitty = make([]Card, len(state.kitty))
-	newState.kitty[0] = state.kitty[0]
-	// Put remaining in kitty
-	for i := 1; i < 4; i++ {
-		for j := 0; j < len(intermediateDeck); j++ {
-			if intermediateDeck[j] != 0 {
-				newState.kitty[i] = intermediateDeck[j]
-				intermediateDeck[j] = 0
-				break
-			}
+	owed := state.handSize - hand.Len()
+	total := binomial(len(eligible), owed)
+	for i, card := range eligible {
+		above := len(eligible) - i - 1
+		if above < owed-1 {
+			break
 		}
+		outcomes = append(outcomes, Action(card))
+		probs = append(probs, binomial(above, owed-1)/total)
 	}
-	newKey := newState.GetInfoSetKey()
-	if key != newKey {
-		panic("Incorrect sampling, key should remain the same")
+
+	return outcomes, probs
+}
+
+// deal resolves a chance action by giving the card to the next hand, or by
+// turning it up and putting the rest of the deck in the kitty
+func (state *EuchreState) deal(card Card) {
+	seat := state.dealingSeat()
+	state.deck = state.deck.Remove(card)
+	if seat != -1 {
+		state.playerHands[seat] = state.playerHands[seat].Add(card)
+		return
 	}
 
-	return newState, nil
+	state.upcard = card
+	state.kitty = state.deck
+	state.deck = 0
+	state.TrumpSuit = card.getSuit()
 }
 
-func inSlice(slice []Suit, suit Suit) bool {
-	for _, item := range slice {
-		if item == suit {
-			return true
-		}
+// binomial returns n choose k
+func binomial(n int, k int) float64 {
+	if k < 0 || k > n {
+		return 0
+	}
+	result := 1.0
+	for i := 0; i < k; i++ {
+		result = result * float64(n-i) / float64(i+1)
 	}
-	return false
+	return result
 }
 
-// Clone ...
-func (state EuchreState) Clone() EuchreState {
-	newState := state
+// SampleWorld implements Sampler
+func (state *EuchreState) SampleWorld() (State, error) {
+	world, err := state.SampleInfoSet()
+	if err != nil {
+		return nil, err
+	}
+	return &world, nil
+}
 
-	// Copy hands
-	for handIdx, hand := range state.playerHands {
-		newState.playerHands[handIdx] = make([]Card, len(hand))
+// Clone returns an independent copy of the state. Every field is a value,
+// so this is a plain copy.
+func (state EuchreState) Clone() EuchreState {
+	return state
+}
 
-		for cIdx, card := range hand {
-			newState.playerHands[handIdx][cIdx] = card
-		}
+// playedCards returns the cards played so far in order. The slice shares
+// the state's storage.
+func (state *EuchreState) playedCards() []Card {
+	return state.history[:state.numPlayed]
+}
 
-	}
+// trick returns the cards of the current trick in order. The slice shares
+// the state's storage.
+func (state *EuchreState) trick() []Card {
+	return state.history[state.numPlayed-state.numPlayed%4 : state.numPlayed]
+}
 
-	// Copy shortsuitedness
-	for handIdx, suits := range state.shortSuited {
-		newState.shortSuited[handIdx] = make([]Suit, len(suits))
+// isVoid reports whether seat has shown out of suit
+func (state *EuchreState) isVoid(seat int, suit Suit) bool {
+	return state.voids[seat]&EffectiveSuitMask(suit, state.TrumpSuit) != 0
+}
 
-		for sIdx, suit := range suits {
-			newState.shortSuited[handIdx][sIdx] = suit
-		}
+// ValidActions returns the cards the current agent may play: any card of
+// the led suit, or any card at all when they have none. While bidding it
+// returns the bids instead.
+func (state *EuchreState) ValidActions() []Action {
+	if state.bidding {
+		return state.bidActions()
 	}
-
-	newState.kitty = make([]Card, len(state.kitty))
-	for cIdx, card := range state.kitty {
-		newState.kitty[cIdx] = card
+	playable := state.validCards()
+	actions := make([]Action, 0, playable.Len())
+	for rest := playable; rest != 0; rest &= rest - 1 {
+		actions = append(actions, Action(rest.Lowest()))
 	}
-
-	return newState
+	return actions
 }
 
-// ValidActions ...
-func (state *EuchreState) ValidActions() []Action {
+// validCards returns the cards the current agent may play as a set
+func (state *EuchreState) validCards() CardSet {
 	hand := state.playerHands[state.currentAgent]
-
-	// Playing the hand
-	var playableActions []Action
 	if state.leadSuit != 0 {
-		// Follow suit if possible
-		playableActions = make([]Action, 0, len(hand))
-		var lastCard Card = 0
-		for _, card := range hand {
-			skipAction := (lastCard != 0) && (TrumpRankTransform(card, state.TrumpSuit) == (lastCard + 1))
-			if !skipAction && (card.effectiveSuit(state.TrumpSuit) == state.leadSuit) {
-				playableActions = append(playableActions, Action(card))
-			}
-
-			lastCard = card
+		if following := hand & EffectiveSuitMask(state.leadSuit, state.TrumpSuit); following != 0 {
+			return following
 		}
-
-		// If no lead suit, play normally
-		if len(playableActions) == 0 {
-			var lastCard Card = 0
-			for _, card := range hand {
-				skipAction := (card == (lastCard + 1))
-				if !skipAction {
-					playableActions = append(playableActions, Action(card))
-				}
-
-				lastCard = card
-			}
-		}
-	} else {
-		// TODO: Fix the action reduction for bowers
-		playableActions = make([]Action, 0, len(hand))
-		var lastCard Card = 0
-		for _, card := range hand {
-			skipAction := (TrumpRankTransform(card, state.TrumpSuit) == (lastCard + 1))
-			if !skipAction {
-				playableActions = append(playableActions, Action(card))
-			}
-
-			lastCard = card
-		}
-	}
-
-	if len(playableActions) > 5 {
-		panic("Too many playable card actions")
 	}
-
-	return playableActions
+	return hand
 }
 
 // Renumbers the cards in the trump and complement suit. This
@@ -329,6 +340,15 @@ func TrumpRankTransform(c Card, trumpSuit Suit) Card {
 
 // TakeAction ...
 func (state *EuchreState) TakeAction(action Action) State {
+	if state.IsChanceNode() {
+		state.deal(Card(action))
+		return State(state)
+	}
+	if state.bidding {
+		state.bid(action)
+		return State(state)
+	}
+
 	narrate := false
 	if narrate {
 		fmt.Println("-----")
@@ -337,7 +357,7 @@ func (state *EuchreState) TakeAction(action Action) State {
 		fmt.Printf("Trump Suit %s\n", state.TrumpSuit.toString())
 		fmt.Printf("Lead Suit %s\n", state.leadSuit.toString())
 		fmt.Printf("Table state:\n")
-		for i, card := range state.table {
+		for i, card := range state.trick() {
 			if i == 0 {
 				fmt.Printf("\tPlayer %d lead the %s\n", state.lead, card.ToString())
 			} else {
@@ -346,57 +366,40 @@ func (state *EuchreState) TakeAction(action Action) State {
 		}
 
 		fmt.Printf("Player %d's hand\n", state.currentAgent)
-		for _, card := range state.playerHands[state.currentAgent] {
+		for _, card := range state.playerHands[state.currentAgent].Cards() {
 			fmt.Printf("\t%s\n", card.ToString())
 		}
 	}
 
 	// Playing a card
 	card := Card(action)
-	state.history = append(state.history, card)
+	if !state.playerHands[state.currentAgent].Contains(card) {
+		panic("Card is not in the player's hand")
+	}
+	state.playerHands[state.currentAgent] = state.playerHands[state.currentAgent].Remove(card)
+	state.history[state.numPlayed] = card
+	state.numPlayed++
+	state.played = state.played.Add(card)
 
 	if narrate {
 		fmt.Printf("Player %d plays the %s.\n", state.currentAgent, card.ToString())
 	}
 
-	state.playerHands[state.currentAgent] = RemoveValue(state.playerHands[state.currentAgent], card)
-	state.table = append(state.table, card)
-
-	// Handle bower lead
+	// A bower lead leads trump
 	if state.leadSuit == 0 {
-		if card == makeCard(state.TrumpSuit.complement(), JACK) {
-			state.leadSuit = state.TrumpSuit
-		} else {
-			state.leadSuit = card.getSuit()
-		}
+		state.leadSuit = card.effectiveSuit(state.TrumpSuit)
 	} else if card.effectiveSuit(state.TrumpSuit) != state.leadSuit {
 		// Track shortsuitedness
-		present := false
-		for _, suit := range state.shortSuited[state.currentAgent] {
-			if state.leadSuit == suit {
-				present = true
-			}
-		}
-		if !present {
-			state.shortSuited[state.currentAgent] = append(state.shortSuited[state.currentAgent], state.leadSuit)
+		mask := EffectiveSuitMask(state.leadSuit, state.TrumpSuit)
+		if state.voids[state.currentAgent]&mask != mask {
+			state.showedOut |= 1 << uint(state.numPlayed-1)
 		}
+		state.voids[state.currentAgent] |= mask
 	}
 
 	// Trick completion
-	if len(state.table) == 4 {
-		rankings := getRankings(state.TrumpSuit, state.leadSuit)
-
-		// Get highest card
-		bestIdx := -1
-		val := -1
-		for idx, card := range state.table {
-			rank := getRank(card, rankings)
-			if rank > val {
-				bestIdx = idx
-				val = rank
-			}
-		}
-		winningPlayer := (bestIdx + state.lead) % 4
+	if state.numPlayed%4 == 0 {
+		winningPlayer := trickWinner(state.history[state.numPlayed-4:state.numPlayed], state.lead, state.TrumpSuit)
 
 		if narrate {
 			fmt.Printf("Player %d wins the trick ", winningPlayer)
@@ -406,8 +409,6 @@ func (state *EuchreState) TakeAction(action Action) State {
 		state.teamTricks[winningPlayer%2]++
 		state.lead = winningPlayer
 		state.currentAgent = winningPlayer
-
-		state.table = make([]Card, 0, 4)
 		state.leadSuit = 0
 	} else {
 		state.currentAgent = (state.currentAgent + 1) % 4
@@ -416,20 +417,262 @@ func (state *EuchreState) TakeAction(action Action) State {
 	return State(state)
 }
 
+// UndoAction implements Undoable. It takes back the last card played, or
+// before play has started the last bid or the last card dealt.
+func (state *EuchreState) UndoAction(action Action) {
+	card := Card(action)
+	if state.numPlayed == 0 {
+		if state.numBids > 0 {
+			state.unbid(action)
+		} else {
+			state.undeal(card)
+		}
+		return
+	}
+	if state.history[state.numPlayed-1] != card {
+		panic("Only the last card played can be undone")
+	}
+
+	if state.numPlayed%4 == 0 {
+		// Taking back the last card of a trick takes back the trick. Which
+		// card won depends only on the cards, so the winner and its place
+		// in the trick give the seat that led.
+		trick := state.history[state.numPlayed-4 : state.numPlayed]
+		winner := state.lead
+		state.teamTricks[winner%2]--
+		state.lead = (winner - winningIndex(trick, state.TrumpSuit) + 4) % 4
+		state.leadSuit = trick[0].effectiveSuit(state.TrumpSuit)
+		state.currentAgent = (state.lead + 3) % 4
+	} else {
+		state.currentAgent = (state.currentAgent + 3) % 4
+	}
+
+	state.numPlayed--
+	if bit := uint32(1) << uint(state.numPlayed); state.showedOut&bit != 0 {
+		state.showedOut &^= bit
+		state.voids[state.currentAgent] &^= EffectiveSuitMask(state.leadSuit, state.TrumpSuit)
+	}
+	if state.numPlayed%4 == 0 {
+		state.leadSuit = 0
+	}
+	state.history[state.numPlayed] = 0
+	state.played = state.played.Remove(card)
+	state.playerHands[state.currentAgent] = state.playerHands[state.currentAgent].Add(card)
+}
+
+// undeal takes back a chance action: the upcard if it has been turned,
+// otherwise a card dealt to a hand
+func (state *EuchreState) undeal(card Card) {
+	if state.upcard != 0 {
+		if state.upcard != card {
+			panic("Only the upcard can be undone once it is turned")
+		}
+		state.deck = state.kitty.Add(card)
+		state.kitty = 0
+		state.upcard = 0
+		state.TrumpSuit = 0
+		return
+	}
+
+	for seat, hand := range state.playerHands {
+		if hand.Contains(card) {
+			state.playerHands[seat] = hand.Remove(card)
+			state.deck = state.deck.Add(card)
+			return
+		}
+	}
+	panic("Card was never dealt")
+}
+
+// trickWinner returns the seat that wins a complete trick
+func trickWinner(trick []Card, leader int, trumpSuit Suit) int {
+	return (winningIndex(trick, trumpSuit) + leader) % 4
+}
+
+// winningIndex returns the place in a complete trick of the card that wins
+// it
+func winningIndex(trick []Card, trumpSuit Suit) int {
+	table := getRankTable(trumpSuit, trick[0].effectiveSuit(trumpSuit))
+
+	// Get highest card
+	bestIdx := -1
+	val := -1
+	for idx, card := range trick {
+		rank := table.rank(card)
+		if rank > val {
+			bestIdx = idx
+			val = rank
+		}
+	}
+	return bestIdx
+}
+
+// historySeats returns the seat that played each card of the history,
+// replaying the tricks from the first lead
+func (state *EuchreState) historySeats() []int {
+	history := state.playedCards()
+	seats := make([]int, len(history))
+	leader := state.firstLead
+	for start := 0; start < len(history); start += 4 {
+		end := start + 4
+		if end > len(history) {
+			end = len(history)
+		}
+		for i := start; i < end; i++ {
+			seats[i] = (leader + i - start) % 4
+		}
+		if end-start == 4 {
+			leader = trickWinner(history[start:end], leader, state.TrumpSuit)
+		}
+	}
+	return seats
+}
+
+// Deal returns the hands as they were dealt, for double dummy analysis.
+// The calling team is given as the maker seat.
+func (state *EuchreState) Deal() Deal {
+	deal := Deal{
+		Trump:  state.TrumpSuit,
+		Maker:  state.callingTeam,
+		Leader: state.firstLead,
+	}
+	hands := state.playerHands
+	for i, seat := range state.historySeats() {
+		hands[seat] = hands[seat].Add(state.history[i])
+	}
+	for seat, hand := range hands {
+		deal.Hands[seat] = hand.Cards()
+	}
+	return deal
+}
+
+// startOfPlay rebuilds the position before the first card was led
+func (state *EuchreState) startOfPlay() EuchreState {
+	deal := state.Deal()
+	start := EuchreState{
+		upcard:       state.upcard,
+		kitty:        state.kitty,
+		TrumpSuit:    state.TrumpSuit,
+		lead:         state.firstLead,
+		firstLead:    state.firstLead,
+		callingTeam:  state.callingTeam,
+		currentAgent: state.firstLead,
+		handSize:     state.handSize,
+		bids:         state.bids,
+		numBids:      state.numBids,
+		KeyMode:      state.KeyMode,
+	}
+	for seat := range start.playerHands {
+		start.playerHands[seat] = NewCardSet(deal.Hands[seat]...)
+	}
+	return start
+}
+
+// equivalentCards reports whether two cards in the hand of seat play the
+// same: they share an effective suit and every card ranked between them is
+// also in that hand or has already been played. Cards on the current trick
+// can still be beaten, so they keep the cards around them apart.
+func (state *EuchreState) equivalentCards(seat int, a Card, b Card) bool {
+	suit := a.effectiveSuit(state.TrumpSuit)
+	if b.effectiveSuit(state.TrumpSuit) != suit {
+		return false
+	}
+
+	table := getRankTable(state.TrumpSuit, suit)
+	low, high := table.rank(a), table.rank(b)
+	if low > high {
+		low, high = high, low
+	}
+	between := table.between(low, high) & EffectiveSuitMask(suit, state.TrumpSuit)
+	known := state.playerHands[seat] | state.played&^NewCardSet(state.trick()...)
+	return between.Minus(known).Empty()
+}
+
+// PlayedCards returns every card played so far in order
+func (state *EuchreState) PlayedCards() []Card {
+	return append([]Card{}, state.playedCards()...)
+}
+
+// OrderedActions implements MoveOrderer. Cards that would take the trick
+// come first, strongest first, followed by the rest weakest first.
+func (state *EuchreState) OrderedActions() []Action {
+	actions := state.ValidActions()
+	// Scores are indexed by card index
+	var scores [24]int
+	if state.leadSuit == 0 {
+		// Leading, so rank each card as if it sets the lead suit
+		for _, action := range actions {
+			card := Card(action)
+			scores[card.index()] = getRankTable(state.TrumpSuit, card.effectiveSuit(state.TrumpSuit)).rank(card)
+		}
+	} else {
+		table := getRankTable(state.TrumpSuit, state.leadSuit)
+		winningRank := -1
+		for _, card := range state.trick() {
+			if rank := table.rank(card); rank > winningRank {
+				winningRank = rank
+			}
+		}
+		for _, action := range actions {
+			card := Card(action)
+			rank := table.rank(card)
+			if rank > winningRank {
+				scores[card.index()] = 100 + rank
+			} else {
+				scores[card.index()] = -rank
+			}
+		}
+	}
+
+	sort.SliceStable(actions, func(j, k int) bool {
+		return scores[Card(actions[j]).index()] > scores[Card(actions[k]).index()]
+	})
+	return actions
+}
+
+// TranspositionKey implements Transposer. Each seat's word holds its hand
+// as a bit mask and the card it has on the table, and the first word also
+// holds the leader, the player to act and the tricks taken. Trump, the
+// calling team and the kitty are fixed for a deal so they are left out.
+func (state *EuchreState) TranspositionKey() PositionKey {
+	var key PositionKey
+	for seat, hand := range state.playerHands {
+		key[seat] |= uint64(hand)
+	}
+	for i, card := range state.trick() {
+		seat := (state.lead + i) % 4
+		key[seat] |= uint64(card.index()+1) << 24
+	}
+	key[0] |= uint64(state.lead)<<32 | uint64(state.currentAgent)<<34 |
+		uint64(state.teamTricks[0])<<36 | uint64(state.teamTricks[1])<<40
+
+	return key
+}
+
 // GetCurrentAgent ...
 func (state EuchreState) GetCurrentAgent() int {
 	return state.currentAgent
 }
 
+// TricksPlayed implements TrickCounter
+func (state *EuchreState) TricksPlayed() int {
+	return state.teamTricks[0] + state.teamTricks[1]
+}
+
 // GetUtility ...
 func (state *EuchreState) GetUtility(playerID int) float64 {
+	return state.utility(state.teamTricks, playerID)
+}
+
+// utility scores the hand for playerID when the teams take teamTricks
+func (state *EuchreState) utility(teamTricks [2]int, playerID int) float64 {
 	playerTeam := playerID % 2
 	nonCallingTeam := 1 - state.callingTeam
 
 	points := [2]int{0, 0}
-	if state.teamTricks[nonCallingTeam] > state.teamTricks[state.callingTeam] {
+	if teamTricks[nonCallingTeam] > teamTricks[state.callingTeam] {
 		points[nonCallingTeam] = 2
-	} else if state.teamTricks[state.callingTeam] == 5 {
+	} else if teamTricks[state.callingTeam] == state.handSize {
 		points[state.callingTeam] = 2
 	} else {
 		points[state.callingTeam] = 1
@@ -438,6 +681,28 @@ func (state *EuchreState) GetUtility(playerID int) float64 {
 	return float64(points[playerTeam] - points[1-playerTeam])
 }
 
+// ProbeEndgame implements EndgameProber. Positions at the start of a trick
+// with few enough cards left are scored as if the remaining tricks were
+// played double dummy.
+func (state *EuchreState) ProbeEndgame(table *EndgameTable, playerID int) (float64, bool) {
+	if state.numPlayed%4 != 0 || state.IsChanceNode() || state.bidding || state.IsTerminal() {
+		return 0, false
+	}
+	remaining := state.playerHands[state.lead].Len()
+	if remaining > table.MaxCards {
+		return 0, false
+	}
+
+	tricks, err := table.Lookup(makeEndgameKey(state.playerHands, state.lead, state.TrumpSuit))
+	if err != nil {
+		return 0, false
+	}
+	teamTricks := state.teamTricks
+	teamTricks[state.lead%2] += tricks
+	teamTricks[1-state.lead%2] += remaining - tricks
+	return state.utility(teamTricks, playerID), true
+}
+
 // TakeActionCopy ...
 func (state EuchreState) TakeActionCopy(action Action) State {
 	clone := state.Clone()
@@ -446,27 +711,34 @@ func (state EuchreState) TakeActionCopy(action Action) State {
 
 // GetInfoSetKey ...
 func (state EuchreState) GetInfoSetKey() InfoSetKey {
+	if state.bidding {
+		return state.biddingKey()
+	}
+	if state.KeyMode == PERFECT_RECALL_KEYS {
+		return state.perfectRecallKey()
+	}
+	return state.compactKey()
+}
+
+// compactKey merges info sets that played the same cards in a different
+// order, keeping the current trick and the voids
+func (state EuchreState) compactKey() InfoSetKey {
 	cardStrings := ""
 
 	// Current Hand
-	for _, card := range state.playerHands[state.currentAgent] {
+	for _, card := range state.playerHands[state.currentAgent].Cards() {
 		cardStrings += fmt.Sprintf("%d", card)
 	}
 	cardStrings += "_"
 
 	// Seen cards
-	seenCards := make([]Card, len(state.history))
-	copy(seenCards, state.history)
-	sort.Slice(seenCards, func(j, k int) bool {
-		return seenCards[j] < seenCards[k]
-	})
-	for _, card := range seenCards {
+	for _, card := range state.played.Cards() {
 		cardStrings += fmt.Sprintf("%d", card)
 	}
 	cardStrings += "_"
 
 	// Table
-	for _, card := range state.table {
+	for _, card := range state.trick() {
 		cardStrings += fmt.Sprintf("%d", card)
 	}
 	cardStrings += "_"
@@ -474,13 +746,12 @@ func (state EuchreState) GetInfoSetKey() InfoSetKey {
 	// Shortsuitedness
 	for i := 1; i < 4; i++ {
 		handIdx := (state.currentAgent + i) % 4
-		suits := []int{0, 0, 0, 0}
-		for _, suit := range state.shortSuited[handIdx] {
-			suitIdx := (suit / 10) - 1
-			suits[suitIdx] = 1
-		}
-		for _, suit := range suits {
-			cardStrings += fmt.Sprintf("%d", suit)
+		for s := DIAMONDS; s <= CLUBS; s += 10 {
+			if state.isVoid(handIdx, s) {
+				cardStrings += "1"
+			} else {
+				cardStrings += "0"
+			}
 		}
 	}
 	cardStrings += "_"
@@ -490,122 +761,128 @@ func (state EuchreState) GetInfoSetKey() InfoSetKey {
 		panic("Incorrect key")
 	}
 
-	return InfoSetKey(cardStrings)
+	return InfoSetKey(cardStrings) + state.bidsKey()
+}
+
+// perfectRecallKey builds the key from the current hand and every card
+// played so far, each tagged with its seat relative to the current agent
+func (state EuchreState) perfectRecallKey() InfoSetKey {
+	cardStrings := ""
+
+	// Current Hand
+	for _, card := range state.playerHands[state.currentAgent].Cards() {
+		cardStrings += fmt.Sprintf("%d", card)
+	}
+	cardStrings += "_"
+
+	// Played cards in order
+	for i, seat := range state.historySeats() {
+		cardStrings += fmt.Sprintf("%d%d", (seat-state.currentAgent+4)%4, state.history[i])
+	}
+	cardStrings += "_"
+
+	// Upcard and whether our team called trump
+	cardStrings += fmt.Sprintf("%d", state.upcard)
+	if state.callingTeam == state.currentAgent%2 {
+		cardStrings += "1"
+	} else {
+		cardStrings += "0"
+	}
+	cardStrings += "_"
+
+	return InfoSetKey(cardStrings) + state.bidsKey()
 }
 
 // IsTerminal ...
 func (state *EuchreState) IsTerminal() bool {
 	nonCallingTeam := 1 - state.callingTeam
-	return (state.teamTricks[state.callingTeam] == 5) || (state.teamTricks[nonCallingTeam] == 3) || (state.teamTricks[0]+state.teamTricks[1] == 5)
+	return (state.teamTricks[state.callingTeam] == state.handSize) || (state.teamTricks[nonCallingTeam] > state.handSize/2) || (state.teamTricks[0]+state.teamTricks[1] == state.handSize)
 }
 
 // Ensures that no cards have been duplicated or lost
 func (state EuchreState) CheckCards() {
-	// Check if cards are all present
-	for value := 1; value < 7; value++ {
-		for suit := 10; suit <= 40; suit += 10 {
-			card := Card(suit + value)
-			pass := state.checkCard(card)
-			if !pass {
-				panic(fmt.Sprintf("Lost %s somewhere", card.ToString()))
-			}
-		}
+	// Every card must be in exactly one place
+	var seen CardSet
+	places := []CardSet{state.played, state.kitty, state.deck}
+	if state.upcard != 0 {
+		places = append(places, cardBit(state.upcard))
 	}
-
-	// Check to make sure the hands are sorted
-	for _, hand := range state.playerHands {
-		last := Card(0)
-		for _, card := range hand {
-			if card <= last {
-				panic("Hands are not sorted")
-			}
-			last = card
+	places = append(places, state.playerHands[:]...)
+	for _, place := range places {
+		if seen&place != 0 {
+			panic(fmt.Sprintf("Duplicated %s", (seen & place).String()))
 		}
+		seen |= place
+	}
+	if seen != ALL_CARDS {
+		panic(fmt.Sprintf("Lost %s somewhere", (ALL_CARDS &^ seen).String()))
 	}
 
-	// Check validity of the shortsuit array
+	// Check validity of the shortsuit tracking
 	for handIdx, hand := range state.playerHands {
-		for _, card := range hand {
-			if inSlice(state.shortSuited[handIdx], card.effectiveSuit(state.TrumpSuit)) {
-				panic(fmt.Sprintf("Shortsuited tracking incorrect"))
-			}
+		if hand&state.voids[handIdx] != 0 {
+			panic(fmt.Sprintf("Shortsuited tracking incorrect"))
 		}
 	}
 }
 
-// Look for a single card and make sure it appears once
-func (state EuchreState) checkCard(c Card) bool {
-	found := false
+// Normalize relabels the suits so that suit becomes spades
+func (state *EuchreState) Normalize(suit Suit) {
+	state.relabel(func(s Suit) Suit { return s.normalizeSuit(suit) })
+}
 
-	for _, hand := range state.playerHands {
-		for _, card := range hand {
-			if card == c {
-				if found {
-					return false
-				}
-				found = true
-			}
-		}
-	}
-	for _, card := range state.kitty {
-		if card == c {
-			if found {
-				return false
-			}
-			found = true
-		}
-	}
-	for _, card := range state.history {
-		if card == c {
-			if found {
-				return false
-			}
-			found = true
+// swapSuits exchanges the labels of two suits
+func (state *EuchreState) swapSuits(a Suit, b Suit) {
+	state.relabel(func(s Suit) Suit {
+		switch s {
+		case a:
+			return b
+		case b:
+			return a
 		}
-	}
-
-	return found
+		return s
+	})
 }
 
-func (state *EuchreState) Normalize(suit Suit) {
+// relabel renames every suit in the state with mapSuit, which must be a
+// permutation of the suits
+func (state *EuchreState) relabel(mapSuit func(Suit) Suit) {
+	mapCard := func(c *Card) {
+		*c = makeCard(mapSuit(c.getSuit()), c.getValue())
+	}
 
-	// Hands
+	// Hands and shortsuitedness
 	for i := range state.playerHands {
-		for j := range state.playerHands[i] {
-			state.playerHands[i][j].normalizeSuit(suit)
-		}
-
-		sort.Slice(state.playerHands[i], func(j, k int) bool {
-			return state.playerHands[i][j] < state.playerHands[i][k]
-		})
+		state.playerHands[i] = state.playerHands[i].relabel(mapSuit)
+		state.voids[i] = state.voids[i].relabel(mapSuit)
 	}
 
 	// History
-	for i := range state.history {
-		state.history[i].normalizeSuit(suit)
-	}
-
-	// Table
-	for i := range state.table {
-		state.table[i].normalizeSuit(suit)
+	for i := range state.playedCards() {
+		mapCard(&state.history[i])
 	}
+	state.played = state.played.relabel(mapSuit)
 
-	// Kitty
-	for i := range state.kitty {
-		state.kitty[i].normalizeSuit(suit)
+	// Upcard, kitty and deck
+	if state.upcard != 0 {
+		mapCard(&state.upcard)
 	}
+	state.kitty = state.kitty.relabel(mapSuit)
+	state.deck = state.deck.relabel(mapSuit)
 
-	// Shortsuitedness
-	for i := range state.shortSuited {
-		for j := range state.shortSuited[i] {
-			state.shortSuited[i][j] = state.shortSuited[i][j].normalizeSuit(suit)
+	// Bids
+	for i := range state.bids {
+		if state.bids[i] != 0 {
+			state.bids[i] = mapSuit(state.bids[i])
 		}
 	}
 
 	// Trump/lead
-	state.TrumpSuit = state.TrumpSuit.normalizeSuit(suit)
+	if state.TrumpSuit != 0 {
+		state.TrumpSuit = mapSuit(state.TrumpSuit)
+	}
 	if state.leadSuit != 0 {
-		state.leadSuit = state.leadSuit.normalizeSuit(suit)
+		state.leadSuit = mapSuit(state.leadSuit)
 	}
 }
 
@@ -632,9 +909,8 @@ func RemoveValue(s []Card, value Card) []Card {
 }
 
 func shuffle(vals []Card) []Card {
-	r := rand.New(rand.NewSource(time.Now().Unix()))
 	ret := make([]Card, len(vals))
-	perm := r.Perm(len(vals))
+	perm := rand.Perm(len(vals))
 	for i, randIndex := range perm {
 		ret[i] = vals[randIndex]
 	}
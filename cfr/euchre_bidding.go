@@ -0,0 +1,139 @@
+package cfr
+
+import (
+	"fmt"
+)
+
+// NewEuchreBiddingDeal returns an undealt hand whose trump is bid for once
+// the cards are dealt. Starting from the first leader each seat may order
+// up the suit of the upcard or pass. If all four pass, each may name one of
+// the other suits or pass, except the last seat which must name one. The
+// caller's team becomes the calling team. The dealer doesn't take up the
+// upcard, so every hand is played as dealt.
+func NewEuchreBiddingDeal() EuchreState {
+	state := NewEuchreDeal()
+	state.openBidding()
+	return state
+}
+
+// openBidding has trump bid for before play. Until a call the calling
+// team is left at 0, so taking the call back can restore it.
+func (state *EuchreState) openBidding() {
+	state.bidding = true
+	state.callingTeam = 0
+}
+
+// Bidding reports whether the current decision is a bid
+func (state *EuchreState) Bidding() bool {
+	return state.bidding && !state.IsChanceNode()
+}
+
+// biddingSuits returns the suits the current bidder may name
+func (state *EuchreState) biddingSuits() []Suit {
+	upcardSuit := state.upcard.getSuit()
+	if state.numBids < 4 {
+		return []Suit{upcardSuit}
+	}
+
+	suits := make([]Suit, 0, 3)
+	for suit := DIAMONDS; suit <= CLUBS; suit += 10 {
+		if suit != upcardSuit {
+			suits = append(suits, suit)
+		}
+	}
+	return suits
+}
+
+// bidActions returns the bids open to the current bidder
+func (state *EuchreState) bidActions() []Action {
+	actions := make([]Action, 0, 4)
+	if state.numBids < 7 {
+		actions = append(actions, Action(PASS_BID))
+	}
+	for _, suit := range state.biddingSuits() {
+		actions = append(actions, Action(suit))
+	}
+	return actions
+}
+
+// bid records the current bidder's action. A call fixes trump and the
+// calling team and hands the lead to the first leader.
+func (state *EuchreState) bid(action Action) {
+	if action == Action(PASS_BID) {
+		state.numBids++
+		state.currentAgent = (state.currentAgent + 1) % 4
+		return
+	}
+
+	suit := Suit(action)
+	state.bids[state.numBids] = suit
+	state.numBids++
+	state.TrumpSuit = suit
+	state.callingTeam = state.currentAgent % 2
+	state.currentAgent = state.firstLead
+	state.bidding = false
+}
+
+// unbid takes back the last bid
+func (state *EuchreState) unbid(action Action) {
+	state.numBids--
+	last := Action(PASS_BID)
+	if state.bids[state.numBids] != 0 {
+		last = Action(state.bids[state.numBids])
+	}
+	if action != last {
+		panic("Only the last bid can be undone")
+	}
+
+	if action != Action(PASS_BID) {
+		state.bids[state.numBids] = 0
+		state.TrumpSuit = state.upcard.getSuit()
+		state.callingTeam = 0
+		state.bidding = true
+	}
+	state.currentAgent = (state.firstLead + state.numBids) % 4
+}
+
+// BiddingHand implements BiddingState
+func (state *EuchreState) BiddingHand() ([]Card, []Suit, bool) {
+	if !state.Bidding() {
+		return nil, nil, false
+	}
+	return state.playerHands[state.currentAgent].Cards(), state.biddingSuits(), true
+}
+
+// PublicBiddingKey implements BiddingState. It holds the bidder's seat
+// counted from the first leader, the upcard and the bids so far.
+func (state *EuchreState) PublicBiddingKey() InfoSetKey {
+	return InfoSetKey(fmt.Sprintf("%d%d_", (state.currentAgent-state.firstLead+4)%4, state.upcard)) + state.bidsKey()
+}
+
+// biddingKey is the info set key of a bid: the bidder's hand followed by
+// the public part
+func (state *EuchreState) biddingKey() InfoSetKey {
+	cardStrings := "bid_"
+	for _, card := range state.playerHands[state.currentAgent].Cards() {
+		cardStrings += fmt.Sprintf("%d", card)
+	}
+	cardStrings += "_"
+	return InfoSetKey(cardStrings) + state.PublicBiddingKey()
+}
+
+// bidsKey lists the bids in order, each as its suit or 0 for a pass. Once
+// trump is called it ends with the caller's seat counted from the current
+// agent. Hands dealt without bidding have an empty bids key.
+func (state *EuchreState) bidsKey() InfoSetKey {
+	if state.numBids == 0 {
+		return ""
+	}
+
+	cardStrings := ""
+	for _, suit := range state.bids[:state.numBids] {
+		cardStrings += fmt.Sprintf("%d", suit/10)
+	}
+	if !state.bidding {
+		caller := (state.firstLead + state.numBids - 1) % 4
+		cardStrings += fmt.Sprintf("%d", (caller-state.currentAgent+4)%4)
+	}
+	return InfoSetKey(cardStrings + "_")
+}
@@ -0,0 +1,81 @@
+package cfr
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestBiddingRounds(t *testing.T) {
+	state := dealHand(rand.New(rand.NewSource(1)), 5)
+	state.openBidding()
+	upcardSuit := state.upcard.getSuit()
+
+	// The first round orders up the upcard or passes
+	actions := state.ValidActions()
+	if len(actions) != 2 || actions[0] != Action(PASS_BID) || actions[1] != Action(upcardSuit) {
+		t.Fatalf("The first bid offers %v", actions)
+	}
+	if !strings.HasPrefix(string(state.GetInfoSetKey()), "bid_") {
+		t.Errorf("The first bid is keyed as %v", state.GetInfoSetKey())
+	}
+
+	// The second round names the other suits, and the dealer can't pass
+	for i := 0; i < 4; i++ {
+		state.TakeAction(Action(PASS_BID))
+	}
+	actions = state.ValidActions()
+	if len(actions) != 4 || actions[0] != Action(PASS_BID) {
+		t.Fatalf("The second round offers %v", actions)
+	}
+	for _, action := range actions[1:] {
+		if Suit(action) == upcardSuit {
+			t.Fatal("The turned down suit can be named")
+		}
+	}
+	for i := 0; i < 3; i++ {
+		state.TakeAction(Action(PASS_BID))
+	}
+	if actions = state.ValidActions(); len(actions) != 3 {
+		t.Fatalf("The dealer may bid %v", actions)
+	}
+
+	// Calling fixes trump and the calling team, then the first leader leads
+	dealer := state.currentAgent
+	state.TakeAction(actions[0])
+	if state.Bidding() {
+		t.Fatal("Bidding continued after a call")
+	}
+	if state.TrumpSuit != Suit(actions[0]) || state.callingTeam != dealer%2 {
+		t.Errorf("Calling %v made %v trump for team %d", actions[0], state.TrumpSuit, state.callingTeam)
+	}
+	if state.currentAgent != state.firstLead {
+		t.Errorf("Seat %d leads instead of %d", state.currentAgent, state.firstLead)
+	}
+	if !isValid(&state, Action(state.playerHands[state.firstLead].Lowest())) {
+		t.Error("The leader can't play a card")
+	}
+	// Play keys end with the bids and the caller's seat from the leader
+	if bids := fmt.Sprintf("0000000%d3_", actions[0]/10); !strings.HasSuffix(string(state.GetInfoSetKey()), bids) {
+		t.Errorf("Play is keyed as %v", state.GetInfoSetKey())
+	}
+}
+
+func TestBiddingAbstractionMapsCalls(t *testing.T) {
+	abstraction := NewEuchreAbstraction()
+	r := rand.New(rand.NewSource(2))
+	for i := 0; i < 20; i++ {
+		state := dealHand(r, 5)
+		state.openBidding()
+		for bids := r.Intn(8); bids > 0; bids-- {
+			state.TakeAction(Action(PASS_BID))
+		}
+
+		for _, action := range abstraction.Actions(&state) {
+			if concrete := abstraction.ConcreteAction(&state, action); !isValid(&state, concrete) {
+				t.Fatalf("Bid %v maps to %v, which can't be bid", action, concrete)
+			}
+		}
+	}
+}
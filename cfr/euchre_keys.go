@@ -0,0 +1,81 @@
+package cfr
+
+// PackedInfoSetKey implements PackedKeyer. The key holds the info set
+// InfoSetKey formats, packed from card masks instead. Compact keys also
+// hold the upcard, who called and the tricks taken. Hands dealt with
+// bidding don't fit, so they are left to hashed keys.
+func (abstraction EuchreAbstraction) PackedInfoSetKey(state State) (PackedKey, bool) {
+	euchreState, ok := underlyingEuchre(state)
+	if !ok || euchreState.bidding || euchreState.numBids > 0 {
+		return PackedKey{}, false
+	}
+	relabeling := abstraction.relabeling(euchreState)
+	if euchreState.KeyMode == PERFECT_RECALL_KEYS {
+		return euchreState.packedPerfectRecallKey(relabeling), true
+	}
+	return euchreState.packedCompactKey(relabeling), true
+}
+
+// cardMask packs a set into a 24 bit mask after relabeling its cards
+func cardMask(set CardSet, relabeling suitRelabeling) uint64 {
+	return uint64(set.relabel(relabeling.abstractSuit))
+}
+
+// packedCompactKey packs what compactKey formats: the hand, the cards seen,
+// the current trick, the voids, the upcard, who called and the tricks
+func (state *EuchreState) packedCompactKey(relabeling suitRelabeling) PackedKey {
+	var packer keyPacker
+	packer.put(cardMask(state.playerHands[state.currentAgent], relabeling), 24)
+	packer.put(cardMask(state.played, relabeling), 24)
+
+	// Current trick in order, 0 for no card
+	trick := state.trick()
+	for i := 0; i < 3; i++ {
+		var slot uint64
+		if i < len(trick) {
+			slot = uint64(relabeling.abstractCard(trick[i]).index() + 1)
+		}
+		packer.put(slot, 5)
+	}
+
+	// Voids of the other seats
+	for i := 1; i < 4; i++ {
+		var voids uint64
+		for s := DIAMONDS; s <= CLUBS; s += 10 {
+			if state.isVoid((state.currentAgent+i)%4, s) {
+				voids |= 1 << uint(relabeling.abstractSuit(s)/10-1)
+			}
+		}
+		packer.put(voids, 4)
+	}
+
+	state.packCommon(&packer, relabeling)
+	team := state.currentAgent % 2
+	packer.put(uint64(state.teamTricks[team]), 3)
+	packer.put(uint64(state.teamTricks[1-team]), 3)
+	return packer.key
+}
+
+// packedPerfectRecallKey packs what perfectRecallKey formats. The seats
+// that played each card follow from the first lead and the cards, so only
+// the first lead is stored.
+func (state *EuchreState) packedPerfectRecallKey(relabeling suitRelabeling) PackedKey {
+	var packer keyPacker
+	packer.put(cardMask(state.playerHands[state.currentAgent], relabeling), 24)
+	state.packCommon(&packer, relabeling)
+	packer.put(uint64(state.firstLead-state.currentAgent+4)%4, 2)
+	for _, card := range state.playedCards() {
+		packer.put(uint64(relabeling.abstractCard(card).index()+1), 5)
+	}
+	return packer.key
+}
+
+// packCommon packs the upcard and whether the current agent's team called
+func (state *EuchreState) packCommon(packer *keyPacker, relabeling suitRelabeling) {
+	packer.put(uint64(relabeling.abstractCard(state.upcard).index()), 5)
+	if state.callingTeam == state.currentAgent%2 {
+		packer.put(1, 1)
+	} else {
+		packer.put(0, 1)
+	}
+}
@@ -0,0 +1,41 @@
+package cfr
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestPackedKeysSplitInfoSetsLikeFormattedKeys(t *testing.T) {
+	abstraction := NewEuchreAbstraction()
+	for _, mode := range []InfoSetKeyMode{COMPACT_KEYS, PERFECT_RECALL_KEYS} {
+		formattedOf := make(map[PackedKey]InfoSetKey)
+		packedOf := make(map[InfoSetKey]PackedKey)
+		states := make([]EuchreState, 0)
+		for _, state := range isomorphismStates(rand.New(rand.NewSource(5))) {
+			// Mirror images share an info set, so each key is met twice
+			mirror := state.Clone()
+			mirror.swapSuits(otherColourSuits(state.TrumpSuit))
+			states = append(states, state, mirror)
+		}
+		for _, state := range states {
+			state.KeyMode = mode
+			packed, ok := abstraction.PackedInfoSetKey(&state)
+			if !ok {
+				t.Fatal("A Euchre state has no packed key")
+			}
+			formatted := abstraction.InfoSetKey(&state)
+
+			// Packed keys never merge info sets the formatted keys keep apart
+			if previous, exists := formattedOf[packed]; exists && previous != formatted {
+				t.Fatalf("Keys %v and %v packed to the same key", previous, formatted)
+			}
+			formattedOf[packed] = formatted
+
+			// Perfect recall keys also never split one
+			if previous, exists := packedOf[formatted]; exists && previous != packed && mode == PERFECT_RECALL_KEYS {
+				t.Fatalf("Key %v packed two ways", formatted)
+			}
+			packedOf[formatted] = packed
+		}
+	}
+}
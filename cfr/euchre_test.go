@@ -0,0 +1,132 @@
+package cfr
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// dealHand deals a random hand of handSize cards from the full deck
+func dealHand(r *rand.Rand, handSize int) EuchreState {
+	deck := make([]Card, 0, 24)
+	for suit := DIAMONDS; suit <= CLUBS; suit += 10 {
+		for value := NINE; value <= ACE; value++ {
+			deck = append(deck, makeCard(suit, value))
+		}
+	}
+	return dealDeck(r, deck, handSize)
+}
+
+// dealDeck deals a random hand of handSize cards from deck
+func dealDeck(r *rand.Rand, deck []Card, handSize int) EuchreState {
+	state := NewEuchreVariant(deck, handSize, r.Intn(4), r.Intn(2))
+	for state.IsChanceNode() {
+		outcomes, probs := state.ChanceOutcomes()
+		num := r.Float64()
+		for i, prob := range probs {
+			num -= prob
+			if num < 0 || i == len(probs)-1 {
+				state.TakeAction(outcomes[i])
+				break
+			}
+		}
+	}
+	return state
+}
+
+// playedInto deals a random hand and plays numPlayed random cards of it
+func playedInto(r *rand.Rand, numPlayed int) EuchreState {
+	state := dealHand(r, 5)
+	for played := 0; played < numPlayed && !state.IsTerminal(); played++ {
+		actions := state.ValidActions()
+		state.TakeAction(actions[r.Intn(len(actions))])
+	}
+	return state
+}
+
+// isValid reports whether action is one of the valid actions of state
+func isValid(state State, action Action) bool {
+	for _, valid := range state.ValidActions() {
+		if valid == action {
+			return true
+		}
+	}
+	return false
+}
+
+// randomAction draws the next action of a random playout
+func randomAction(r *rand.Rand, state *EuchreState) Action {
+	if state.IsChanceNode() {
+		outcomes, _ := state.ChanceOutcomes()
+		return outcomes[r.Intn(len(outcomes))]
+	}
+	actions := state.ValidActions()
+	return actions[r.Intn(len(actions))]
+}
+
+// newDeal starts every other hand with a round of bidding
+func newDeal(game int) EuchreState {
+	if game%2 == 0 {
+		return NewEuchreDeal()
+	}
+	return NewEuchreBiddingDeal()
+}
+
+func TestUndoActionRestoresEveryChild(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for game := 0; game < 500; game++ {
+		state := newDeal(game)
+		for !state.IsTerminal() {
+			actions := state.ValidActions()
+			if state.IsChanceNode() {
+				actions, _ = state.ChanceOutcomes()
+			}
+			for _, action := range actions {
+				before := state
+				state.TakeAction(action)
+				state.UndoAction(action)
+				if state != before {
+					t.Fatalf("Undoing %v changed the state\nbefore %+v\nafter  %+v", action, before, state)
+				}
+			}
+			state.TakeAction(randomAction(r, &state))
+			if !state.IsChanceNode() {
+				state.CheckCards()
+			}
+		}
+	}
+}
+
+func TestUndoActionUnwindsAWholeHand(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for game := 0; game < 500; game++ {
+		state := newDeal(game)
+		var states []EuchreState
+		var taken []Action
+		for !state.IsTerminal() {
+			states = append(states, state)
+			action := randomAction(r, &state)
+			taken = append(taken, action)
+			state.TakeAction(action)
+		}
+
+		for i := len(taken) - 1; i >= 0; i-- {
+			state.UndoAction(taken[i])
+			if state != states[i] {
+				t.Fatalf("Undoing action %d of %d didn't restore the state", i, len(taken))
+			}
+		}
+	}
+}
+
+func TestTakeActionRejectsCardsNotHeld(t *testing.T) {
+	state := NewEuchreState()
+	held := state.playerHands[state.currentAgent]
+	notHeld := ALL_CARDS.Minus(held).Lowest()
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Playing a card that isn't held should panic")
+		}
+	}()
+	state.TakeAction(Action(notHeld))
+}
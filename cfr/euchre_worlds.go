@@ -0,0 +1,270 @@
+package cfr
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+)
+
+// hiddenLayout describes the cards hidden from the current agent and who
+// can hold them. Holders 0-2 are the other seats in turn order and holder 3
+// is the kitty under the upcard. A seat that showed out of a suit can't
+// hold any of its cards.
+type hiddenLayout struct {
+	// Hidden cards grouped by effective suit, indexed by suit/10 - 1
+	groups   [4][]Card
+	seats    [3]int
+	capacity [4]int
+	allowed  [4][4]bool
+	memo     map[layoutPosition]float64
+}
+
+type layoutPosition struct {
+	group    int
+	capacity [4]int
+}
+
+func (state *EuchreState) hiddenLayout() *hiddenLayout {
+	var seats [3]int
+	var capacity [4]int
+	unseen := state.kitty
+	for h := range seats {
+		seats[h] = (state.currentAgent + h + 1) % 4
+		capacity[h] = state.playerHands[seats[h]].Len()
+		unseen |= state.playerHands[seats[h]]
+	}
+	capacity[3] = state.kitty.Len()
+	return newHiddenLayout(unseen.Cards(), state.TrumpSuit, seats, capacity, state.voids)
+}
+
+// newHiddenLayout groups the unseen cards for dealing to the seats and the
+// kitty. voids holds the cards each seat can't hold, indexed by seat.
+func newHiddenLayout(unseen []Card, trumpSuit Suit, seats [3]int, capacity [4]int, voids [4]CardSet) *hiddenLayout {
+	layout := hiddenLayout{
+		seats:    seats,
+		capacity: capacity,
+		memo:     make(map[layoutPosition]float64),
+	}
+	for _, card := range unseen {
+		suit := card.effectiveSuit(trumpSuit)
+		layout.groups[suit/10-1] = append(layout.groups[suit/10-1], card)
+	}
+
+	for g := range layout.groups {
+		sort.Slice(layout.groups[g], func(j, k int) bool {
+			return layout.groups[g][j] < layout.groups[g][k]
+		})
+		suit := Suit((g + 1) * 10)
+		for h, seat := range layout.seats {
+			layout.allowed[g][h] = voids[seat]&EffectiveSuitMask(suit, trumpSuit) == 0
+		}
+		layout.allowed[g][3] = true
+	}
+	return &layout
+}
+
+// count returns the number of ways to deal the groups from group on into
+// the remaining capacity
+func (layout *hiddenLayout) count(group int, capacity [4]int) float64 {
+	if group == 4 {
+		if capacity == [4]int{} {
+			return 1
+		}
+		return 0
+	}
+
+	position := layoutPosition{group: group, capacity: capacity}
+	if ways, exists := layout.memo[position]; exists {
+		return ways
+	}
+	ways := 0.0
+	layout.splits(group, capacity, func(split [4]int, choices float64, rest float64) {
+		ways += choices * rest
+	})
+	layout.memo[position] = ways
+	return ways
+}
+
+// splits calls visit with every way to divide the cards of group between
+// the holders that can be completed, along with the number of ways to pick
+// the cards for it and the number of layouts of the remaining groups that
+// follow from it
+func (layout *hiddenLayout) splits(group int, capacity [4]int, visit func(split [4]int, choices float64, rest float64)) {
+	var split [4]int
+	var divide func(holder int, left int)
+	divide = func(holder int, left int) {
+		if holder == 3 {
+			if left > capacity[3] {
+				return
+			}
+			split[3] = left
+			rest := capacity
+			for h := range rest {
+				rest[h] -= split[h]
+			}
+			if ways := layout.count(group+1, rest); ways > 0 {
+				// Choose which cards of the group each holder gets
+				choices := 1.0
+				remaining := len(layout.groups[group])
+				for _, k := range split {
+					choices *= binomial(remaining, k)
+					remaining -= k
+				}
+				visit(split, choices, ways)
+			}
+			return
+		}
+
+		for k := 0; k <= left && k <= capacity[holder]; k++ {
+			if k > 0 && !layout.allowed[group][holder] {
+				break
+			}
+			split[holder] = k
+			divide(holder+1, left-k)
+		}
+	}
+	divide(0, len(layout.groups[group]))
+}
+
+// sample deals every hidden card uniformly among the consistent layouts
+func (layout *hiddenLayout) sample() ([4][]Card, error) {
+	var dealt [4][]Card
+	capacity := layout.capacity
+	if layout.count(0, capacity) == 0 {
+		return dealt, errors.New("No deal is consistent with the info set")
+	}
+
+	for group := range layout.groups {
+		// Pick how the group is divided in proportion to its layouts
+		total := layout.count(group, capacity)
+		num := rand.Float64() * total
+		sum := 0.0
+		var chosen [4]int
+		found := false
+		layout.splits(group, capacity, func(split [4]int, choices float64, rest float64) {
+			sum += choices * rest
+			if !found && sum > num {
+				chosen = split
+				found = true
+			}
+		})
+		if !found {
+			// Guard against rounding in the sums
+			layout.splits(group, capacity, func(split [4]int, choices float64, rest float64) {
+				chosen = split
+			})
+		}
+
+		cards := shuffle(layout.groups[group])
+		for h, k := range chosen {
+			dealt[h] = append(dealt[h], cards[:k]...)
+			cards = cards[k:]
+			capacity[h] -= k
+		}
+	}
+	return dealt, nil
+}
+
+// holdProbabilities returns, for every group and holder, the probability
+// that the holder gets any one card of the group. Cards of a group are
+// interchangeable, so that is the expected number the holder gets divided
+// by the size of the group.
+func (layout *hiddenLayout) holdProbabilities() [4][4]float64 {
+	var probs [4][4]float64
+	total := layout.count(0, layout.capacity)
+	if total == 0 {
+		return probs
+	}
+
+	// Ways to deal the groups before each one, by the capacity they leave
+	before := map[[4]int]float64{layout.capacity: 1}
+	for group := range layout.groups {
+		after := make(map[[4]int]float64)
+		for capacity, ways := range before {
+			layout.splits(group, capacity, func(split [4]int, choices float64, rest float64) {
+				weight := ways * choices * rest / total
+				next := capacity
+				for h, k := range split {
+					probs[group][h] += weight * float64(k)
+					next[h] -= k
+				}
+				after[next] += ways * choices
+			})
+		}
+		for h := range probs[group] {
+			if len(layout.groups[group]) > 0 {
+				probs[group][h] /= float64(len(layout.groups[group]))
+			}
+		}
+		before = after
+	}
+	return probs
+}
+
+// CountWorlds returns the number of deals of the hidden cards that are
+// consistent with the current agent's info set
+func (state *EuchreState) CountWorlds() float64 {
+	layout := state.hiddenLayout()
+	return layout.count(0, layout.capacity)
+}
+
+// SampleInfoSet deals the cards hidden from the current agent uniformly
+// among every layout consistent with the hand sizes, the kitty and the
+// suits each seat has shown out of
+func (state EuchreState) SampleInfoSet() (EuchreState, error) {
+	layout := state.hiddenLayout()
+	dealt, err := layout.sample()
+	if err != nil {
+		return EuchreState{}, err
+	}
+	return state.withHidden(layout, dealt), nil
+}
+
+// EnumerateWorlds returns every consistent deal of the hidden cards when
+// there are at most maxWorlds of them. Each is equally likely.
+func (state *EuchreState) EnumerateWorlds(maxWorlds int) ([]EuchreState, bool) {
+	layout := state.hiddenLayout()
+	if layout.count(0, layout.capacity) > float64(maxWorlds) {
+		return nil, false
+	}
+
+	hidden := make([]Card, 0)
+	for _, group := range layout.groups {
+		hidden = append(hidden, group...)
+	}
+
+	worlds := make([]EuchreState, 0)
+	var dealt [4][]Card
+	capacity := layout.capacity
+	var deal func(i int)
+	deal = func(i int) {
+		if i == len(hidden) {
+			worlds = append(worlds, state.withHidden(layout, dealt))
+			return
+		}
+		suit := hidden[i].effectiveSuit(state.TrumpSuit)
+		for h := range dealt {
+			if capacity[h] == 0 || !layout.allowed[suit/10-1][h] {
+				continue
+			}
+			dealt[h] = append(dealt[h], hidden[i])
+			capacity[h]--
+			deal(i + 1)
+			capacity[h]++
+			dealt[h] = dealt[h][:len(dealt[h])-1]
+		}
+	}
+	deal(0)
+	return worlds, true
+}
+
+// withHidden returns a copy of the state with the hidden cards dealt to the
+// holders of layout
+func (state EuchreState) withHidden(layout *hiddenLayout, dealt [4][]Card) EuchreState {
+	world := state.Clone()
+	for h, seat := range layout.seats {
+		world.playerHands[seat] = NewCardSet(dealt[h]...)
+	}
+	world.kitty = NewCardSet(dealt[3]...)
+	return world
+}
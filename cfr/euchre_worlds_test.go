@@ -0,0 +1,96 @@
+package cfr
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// bruteForceWorlds counts the deals of the hidden cards by trying every
+// holder for every card, checking the voids card by card
+func bruteForceWorlds(state *EuchreState) int {
+	var seats [3]int
+	var capacity [4]int
+	var cards []Card
+	for h := range seats {
+		seats[h] = (state.currentAgent + h + 1) % 4
+		capacity[h] = state.playerHands[seats[h]].Len()
+		cards = append(cards, state.playerHands[seats[h]].Cards()...)
+	}
+	capacity[3] = state.kitty.Len()
+	cards = append(cards, state.kitty.Cards()...)
+
+	var deal func(i int) int
+	deal = func(i int) int {
+		if i == len(cards) {
+			return 1
+		}
+		worlds := 0
+		for h := range capacity {
+			if capacity[h] == 0 || (h < 3 && state.voids[seats[h]].Contains(cards[i])) {
+				continue
+			}
+			capacity[h]--
+			worlds += deal(i + 1)
+			capacity[h]++
+		}
+		return worlds
+	}
+	return deal(0)
+}
+
+func TestCountWorldsMatchesEnumeration(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	withVoids := 0
+	for trial := 0; trial < 60; trial++ {
+		state := playedInto(r, 8+r.Intn(8))
+		if state.IsTerminal() {
+			continue
+		}
+		for _, voids := range state.voids {
+			if !voids.Empty() {
+				withVoids++
+				break
+			}
+		}
+
+		expected := bruteForceWorlds(&state)
+		if count := state.CountWorlds(); count != float64(expected) {
+			t.Fatalf("CountWorlds gives %v deals, enumeration finds %d", count, expected)
+		}
+		if worlds, ok := state.EnumerateWorlds(expected); !ok || len(worlds) != expected {
+			t.Fatalf("EnumerateWorlds gives %d deals, enumeration finds %d", len(worlds), expected)
+		}
+	}
+	if withVoids == 0 {
+		t.Error("No position had a void, so the voids went untested")
+	}
+}
+
+func TestSampleInfoSetIsUniform(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	state := playedInto(r, 14)
+	for state.IsTerminal() || state.CountWorlds() < 4 || state.CountWorlds() > 60 {
+		state = playedInto(r, 12+r.Intn(4))
+	}
+
+	numWorlds := int(state.CountWorlds())
+	seen := make(map[[4]CardSet]int)
+	numSamples := 400 * numWorlds
+	for s := 0; s < numSamples; s++ {
+		world, err := state.SampleInfoSet()
+		if err != nil {
+			t.Fatal(err)
+		}
+		world.CheckCards()
+		seen[world.playerHands]++
+	}
+	if len(seen) != numWorlds {
+		t.Fatalf("Sampled %d distinct deals out of %d", len(seen), numWorlds)
+	}
+	for hands, hits := range seen {
+		// 400 expected hits, so a quarter off is over 5 standard deviations
+		if hits < 300 || hits > 500 {
+			t.Errorf("Deal %v was sampled %d times, expected about 400", hands, hits)
+		}
+	}
+}
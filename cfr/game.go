@@ -17,6 +17,12 @@ func (game *Game) Play() []float64 {
 	// While the game is not complete
 	turnsTaken := 0
 	for !game.GameState.IsTerminal() {
+		// Chance nodes are resolved by the game rather than an agent
+		if game.GameState.IsChanceNode() {
+			game.GameState.TakeAction(SampleChanceOutcome(game.GameState))
+			continue
+		}
+
 		currentAgent := game.GameState.GetCurrentAgent()
 
 		agentAction := game.Agents[currentAgent].Act(game.GameState)
@@ -30,8 +36,8 @@ func (game *Game) Play() []float64 {
 	}
 
 	// Get resulting utility
-	playerUtilities := make([]float64, 4)
-	for i := 0; i < 4; i++ {
+	playerUtilities := make([]float64, len(game.Agents))
+	for i := range playerUtilities {
 		playerUtilities[i] = game.GameState.GetUtility(i)
 	}
 
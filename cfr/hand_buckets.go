@@ -0,0 +1,255 @@
+package cfr
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// HandBucketer clusters hands into buckets of similar strength, so info
+// sets that differ only in which of those hands is held can share a key
+type HandBucketer interface {
+	// Bucket returns the bucket of hand if trumpSuit were trump
+	Bucket(hand []Card, trumpSuit Suit) int
+	// NumBuckets returns how many buckets there are
+	NumBuckets() int
+}
+
+// HandFeatures summarizes a hand for a trump suit
+type HandFeatures struct {
+	// Trumps held, counting the left bower
+	Trumps int
+	// Bowers held
+	Bowers int
+	// Aces held outside trump
+	OffAces int
+	// Suits other than trump with no cards in the hand
+	Voids int
+}
+
+// NewHandFeatures computes the features of hand if trumpSuit were trump
+func NewHandFeatures(hand []Card, trumpSuit Suit) HandFeatures {
+	var features HandFeatures
+	held := make(map[Suit]bool)
+	for _, card := range hand {
+		suit := card.effectiveSuit(trumpSuit)
+		held[suit] = true
+		if suit == trumpSuit {
+			features.Trumps++
+			if card.getValue() == JACK {
+				features.Bowers++
+			}
+		} else if card.getValue() == ACE {
+			features.OffAces++
+		}
+	}
+	for s := DIAMONDS; s <= CLUBS; s += 10 {
+		if s != trumpSuit && !held[s] {
+			features.Voids++
+		}
+	}
+	return features
+}
+
+// FeatureBucketer buckets hands by their HandFeatures. Every combination of
+// features is its own bucket, so hands in a bucket differ only in their
+// low trumps and off-suit kings and below.
+type FeatureBucketer struct{}
+
+// Bucket implements HandBucketer
+func (bucketer FeatureBucketer) Bucket(hand []Card, trumpSuit Suit) int {
+	features := NewHandFeatures(hand, trumpSuit)
+	return ((features.Trumps*3+features.Bowers)*4+features.OffAces)*4 + features.Voids
+}
+
+// NumBuckets implements HandBucketer
+func (bucketer FeatureBucketer) NumBuckets() int {
+	return 6 * 3 * 4 * 4
+}
+
+// EquityBucketer buckets hands by their equity: the mean number of tricks
+// the holder's team takes under double dummy play when they make trump,
+// over deals of the other cards. Deals are drawn from a seed derived from
+// the hand, so a hand always lands in the same bucket. Equities are cached,
+// and it is safe for concurrent use.
+type EquityBucketer struct {
+	// Deals of the other cards averaged per hand. Below 1 a single deal is
+	// used.
+	NumWorlds int
+	// Boundaries between buckets in ascending order. A hand's bucket is the
+	// number of boundaries its equity reaches.
+	Boundaries []float64
+	// Leader is the seat that leads the first trick, counted from the holder
+	Leader int
+
+	lock     sync.RWMutex
+	equities map[uint32]float64
+}
+
+// NewEquityBucketer returns a bucketer that splits hands at every whole
+// number of tricks, with the holder's left hand opponent leading
+func NewEquityBucketer(numWorlds int) *EquityBucketer {
+	return &EquityBucketer{
+		NumWorlds:  numWorlds,
+		Boundaries: []float64{1.5, 2.5, 3.5, 4.5},
+		Leader:     1,
+		equities:   make(map[uint32]float64),
+	}
+}
+
+// Bucket implements HandBucketer
+func (bucketer *EquityBucketer) Bucket(hand []Card, trumpSuit Suit) int {
+	return sort.SearchFloat64s(bucketer.Boundaries, bucketer.Equity(hand, trumpSuit)+1e-9)
+}
+
+// NumBuckets implements HandBucketer
+func (bucketer *EquityBucketer) NumBuckets() int {
+	return len(bucketer.Boundaries) + 1
+}
+
+// Equity returns the mean tricks the holder's team takes with hand if
+// trumpSuit were trump
+func (bucketer *EquityBucketer) Equity(hand []Card, trumpSuit Suit) float64 {
+	// Equity doesn't depend on which suit is trump, only on the cards
+	// relative to it
+	var mask uint32
+	for _, card := range hand {
+		card.normalizeSuit(trumpSuit)
+		mask |= 1 << uint(card.index())
+	}
+
+	bucketer.lock.RLock()
+	equity, exists := bucketer.equities[mask]
+	bucketer.lock.RUnlock()
+	if exists {
+		return equity
+	}
+
+	equity = bucketer.solve(mask)
+	bucketer.lock.Lock()
+	defer bucketer.lock.Unlock()
+	// Another caller may have solved the hand in the meantime
+	if cached, exists := bucketer.equities[mask]; exists {
+		return cached
+	}
+	if bucketer.equities == nil {
+		bucketer.equities = make(map[uint32]float64)
+	}
+	bucketer.equities[mask] = equity
+	return equity
+}
+
+// solve averages the double dummy tricks of the hand in mask, with spades
+// as trump, over deals of the remaining cards
+func (bucketer *EquityBucketer) solve(mask uint32) float64 {
+	held := make([]Card, 0, 5)
+	rest := make([]Card, 0, 24)
+	for idx := 0; idx < 24; idx++ {
+		if mask&(1<<uint(idx)) != 0 {
+			held = append(held, cardFromIndex(idx))
+		} else {
+			rest = append(rest, cardFromIndex(idx))
+		}
+	}
+
+	numWorlds := bucketer.NumWorlds
+	if numWorlds < 1 {
+		numWorlds = 1
+	}
+
+	r := rand.New(rand.NewSource(int64(mask)))
+	tricks := 0
+	for w := 0; w < numWorlds; w++ {
+		r.Shuffle(len(rest), func(i, j int) {
+			rest[i], rest[j] = rest[j], rest[i]
+		})
+		deal := Deal{Trump: SPADES, Maker: 0, Leader: bucketer.Leader % 4}
+		deal.Hands[0] = append([]Card{}, held...)
+		for seat := 1; seat < 4; seat++ {
+			deal.Hands[seat] = append([]Card{}, rest[(seat-1)*len(held):seat*len(held)]...)
+		}
+
+		solver, err := NewDoubleDummySolver(deal)
+		if err != nil {
+			panic(err)
+		}
+		tricks += solver.Solve().Tricks[0]
+	}
+	return float64(tricks) / float64(numWorlds)
+}
+
+// BiddingState is implemented by states whose current decision is a bid.
+// The bids open to a player don't depend on the cards they hold, so the
+// hand can be replaced by its bucket without changing the actions.
+type BiddingState interface {
+	// BiddingHand returns the hand of the player to bid and the suits
+	// they could name as trump, or false when the decision isn't a bid
+	BiddingHand() (hand []Card, trumpSuits []Suit, ok bool)
+	// PublicBiddingKey returns the rest of the bidder's info set: their
+	// seat, the upcard and the bids made so far
+	PublicBiddingKey() InfoSetKey
+}
+
+// BucketAbstraction keys bidding decisions on the bucket of the bidder's
+// hand for each suit they could name, and leaves every other decision to
+// Base. Euchre hands only have bidding decisions when dealt with
+// NewEuchreBiddingDeal.
+type BucketAbstraction struct {
+	Buckets HandBucketer
+	// Base handles the decisions that aren't bids. Nil uses the game's own
+	// info sets and actions.
+	Base Abstraction
+}
+
+// InfoSetKey implements Abstraction
+func (abstraction BucketAbstraction) InfoSetKey(state State) InfoSetKey {
+	if bidding, ok := state.(BiddingState); ok {
+		if hand, trumpSuits, ok := bidding.BiddingHand(); ok {
+			key := "bid_"
+			for _, suit := range trumpSuits {
+				key += fmt.Sprintf("%d:%d_", suit, abstraction.Buckets.Bucket(hand, suit))
+			}
+			return InfoSetKey(key) + bidding.PublicBiddingKey()
+		}
+	}
+	if abstraction.Base == nil {
+		return state.GetInfoSetKey()
+	}
+	return abstraction.Base.InfoSetKey(state)
+}
+
+// PackedInfoSetKey implements PackedKeyer for the decisions left to a Base
+// that packs its keys
+func (abstraction BucketAbstraction) PackedInfoSetKey(state State) (PackedKey, bool) {
+	packer, ok := abstraction.Base.(PackedKeyer)
+	if !ok || abstraction.isBid(state) {
+		return PackedKey{}, false
+	}
+	return packer.PackedInfoSetKey(state)
+}
+
+// Actions implements Abstraction
+func (abstraction BucketAbstraction) Actions(state State) []Action {
+	if abstraction.Base == nil || abstraction.isBid(state) {
+		return state.ValidActions()
+	}
+	return abstraction.Base.Actions(state)
+}
+
+// ConcreteAction implements Abstraction
+func (abstraction BucketAbstraction) ConcreteAction(state State, action Action) Action {
+	if abstraction.Base == nil || abstraction.isBid(state) {
+		return action
+	}
+	return abstraction.Base.ConcreteAction(state, action)
+}
+
+func (abstraction BucketAbstraction) isBid(state State) bool {
+	bidding, ok := state.(BiddingState)
+	if !ok {
+		return false
+	}
+	_, _, ok = bidding.BiddingHand()
+	return ok
+}
@@ -0,0 +1,204 @@
+package cfr
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestHandFeatures(t *testing.T) {
+	tests := []struct {
+		name     string
+		hand     []Card
+		features HandFeatures
+	}{
+		{
+			"both bowers and two off aces",
+			[]Card{makeCard(SPADES, JACK), makeCard(CLUBS, JACK), makeCard(SPADES, ACE), makeCard(HEARTS, ACE), makeCard(DIAMONDS, ACE)},
+			HandFeatures{Trumps: 3, Bowers: 2, OffAces: 2, Voids: 1},
+		},
+		{
+			"no trump",
+			[]Card{makeCard(HEARTS, NINE), makeCard(HEARTS, TEN), makeCard(DIAMONDS, NINE), makeCard(DIAMONDS, TEN), makeCard(CLUBS, NINE)},
+			HandFeatures{Trumps: 0, Bowers: 0, OffAces: 0, Voids: 0},
+		},
+		{
+			"two suited",
+			[]Card{makeCard(SPADES, NINE), makeCard(SPADES, TEN), makeCard(SPADES, QUEEN), makeCard(HEARTS, ACE), makeCard(HEARTS, KING)},
+			HandFeatures{Trumps: 3, Bowers: 0, OffAces: 1, Voids: 2},
+		},
+	}
+
+	for _, test := range tests {
+		if features := NewHandFeatures(test.hand, SPADES); features != test.features {
+			t.Errorf("%s: got %+v, expected %+v", test.name, features, test.features)
+		}
+	}
+}
+
+func TestFeatureBucketer(t *testing.T) {
+	bucketer := FeatureBucketer{}
+
+	// Low cards of a suit can be exchanged without changing the bucket
+	low := []Card{makeCard(SPADES, JACK), makeCard(SPADES, NINE), makeCard(HEARTS, NINE), makeCard(HEARTS, ACE), makeCard(DIAMONDS, TEN)}
+	other := []Card{makeCard(SPADES, JACK), makeCard(SPADES, KING), makeCard(HEARTS, QUEEN), makeCard(HEARTS, ACE), makeCard(DIAMONDS, KING)}
+	if a, b := bucketer.Bucket(low, SPADES), bucketer.Bucket(other, SPADES); a != b {
+		t.Errorf("Hands with the same features landed in buckets %d and %d", a, b)
+	}
+
+	// Hands with different features never share a bucket
+	seen := make(map[int]HandFeatures)
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		hand := dealHand(r, 5).playerHands[0].Cards()
+		trumpSuit := Suit(10 * (1 + r.Intn(4)))
+		bucket := bucketer.Bucket(hand, trumpSuit)
+		if bucket < 0 || bucket >= bucketer.NumBuckets() {
+			t.Fatalf("Bucket %d is out of range", bucket)
+		}
+		features := NewHandFeatures(hand, trumpSuit)
+		if previous, exists := seen[bucket]; exists && previous != features {
+			t.Fatalf("Features %+v and %+v share bucket %d", previous, features, bucket)
+		}
+		seen[bucket] = features
+	}
+}
+
+func TestEquityBucketer(t *testing.T) {
+	bucketer := NewEquityBucketer(4)
+
+	// The top five trumps take every trick
+	strong := []Card{makeCard(SPADES, JACK), makeCard(CLUBS, JACK), makeCard(SPADES, ACE), makeCard(SPADES, KING), makeCard(SPADES, QUEEN)}
+	if equity := bucketer.Equity(strong, SPADES); equity != 5 {
+		t.Errorf("The top five trumps have equity %v", equity)
+	}
+	if bucket := bucketer.Bucket(strong, SPADES); bucket != bucketer.NumBuckets()-1 {
+		t.Errorf("The top five trumps landed in bucket %d", bucket)
+	}
+
+	weak := []Card{makeCard(HEARTS, NINE), makeCard(HEARTS, TEN), makeCard(DIAMONDS, NINE), makeCard(DIAMONDS, TEN), makeCard(CLUBS, NINE)}
+	if bucketer.Bucket(weak, SPADES) >= bucketer.Bucket(strong, SPADES) {
+		t.Error("A hand without trump bucketed with the top five trumps")
+	}
+
+	// Only the cards relative to trump matter
+	r := rand.New(rand.NewSource(2))
+	for i := 0; i < 5; i++ {
+		hand := dealHand(r, 5).playerHands[0].Cards()
+		relabeled := make([]Card, len(hand))
+		for j, card := range hand {
+			card.normalizeSuit(HEARTS)
+			relabeled[j] = card
+		}
+		if a, b := bucketer.Equity(hand, SPADES), bucketer.Equity(relabeled, HEARTS); a != b {
+			t.Fatalf("Relabeling trump changed the equity from %v to %v", a, b)
+		}
+	}
+}
+
+func TestEquityBucketerZeroValue(t *testing.T) {
+	var bucketer EquityBucketer
+	hand := []Card{makeCard(SPADES, JACK), makeCard(SPADES, NINE), makeCard(HEARTS, NINE), makeCard(HEARTS, ACE), makeCard(DIAMONDS, TEN)}
+
+	// No worlds averages a single deal
+	single := NewEquityBucketer(1)
+	single.Leader = 0
+	if a, b := bucketer.Equity(hand, SPADES), single.Equity(hand, SPADES); a != b {
+		t.Errorf("Zero worlds gave equity %v, one world gave %v", a, b)
+	}
+	if bucket := bucketer.Bucket(hand, SPADES); bucket != 0 {
+		t.Errorf("No boundaries put the hand in bucket %d", bucket)
+	}
+}
+
+// sameFeaturesSwap exchanges a low off-suit card of the bidder for a card
+// of another seat with the same features, returning false if there is none
+func sameFeaturesSwap(state *EuchreState, trumpSuit Suit) bool {
+	bidder := state.currentAgent
+	low := func(card Card) bool {
+		return card.effectiveSuit(trumpSuit) != trumpSuit && card.getValue() != ACE
+	}
+	for _, card := range state.playerHands[bidder].Cards() {
+		if !low(card) {
+			continue
+		}
+		for seat := range state.playerHands {
+			if seat == bidder {
+				continue
+			}
+			for _, swap := range state.playerHands[seat].Cards() {
+				if low(swap) && swap.getSuit() == card.getSuit() {
+					state.playerHands[bidder] = state.playerHands[bidder].Remove(card).Add(swap)
+					state.playerHands[seat] = state.playerHands[seat].Remove(swap).Add(card)
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func TestBucketAbstractionKeysBids(t *testing.T) {
+	abstraction := BucketAbstraction{Buckets: FeatureBucketer{}, Base: NewEuchreAbstraction()}
+	r := rand.New(rand.NewSource(3))
+	swapped := 0
+	for i := 0; i < 50; i++ {
+		state := dealHand(r, 5)
+		state.openBidding()
+
+		key := abstraction.InfoSetKey(&state)
+		if !strings.HasPrefix(string(key), "bid_") {
+			t.Fatalf("Bid keyed as %v", key)
+		}
+
+		other := state.Clone()
+		if !sameFeaturesSwap(&other, state.upcard.getSuit()) {
+			continue
+		}
+		swapped++
+		if other.GetInfoSetKey() == state.GetInfoSetKey() {
+			t.Fatal("Swapping cards left the hand unchanged")
+		}
+		if otherKey := abstraction.InfoSetKey(&other); otherKey != key {
+			t.Fatalf("Hands in the same bucket keyed as %v and %v", key, otherKey)
+		}
+	}
+	if swapped == 0 {
+		t.Error("No deal had cards to swap")
+	}
+
+	// Once trump is called play goes to the base abstraction
+	state := dealHand(r, 5)
+	state.openBidding()
+	state.TakeAction(Action(state.upcard.getSuit()))
+	if key, base := abstraction.InfoSetKey(&state), abstraction.Base.InfoSetKey(&state); key != base {
+		t.Errorf("Play keyed as %v rather than %v", key, base)
+	}
+}
+
+func TestStrategyTrainsOnBuckets(t *testing.T) {
+	deck := []Card{makeCard(HEARTS, ACE), makeCard(DIAMONDS, ACE), makeCard(CLUBS, ACE)}
+	for value := NINE; value <= ACE; value++ {
+		deck = append(deck, makeCard(SPADES, value))
+	}
+	state := dealDeck(rand.New(rand.NewSource(4)), deck, 2)
+	state.openBidding()
+
+	strat := NewStrategy()
+	strat.Abstraction = BucketAbstraction{Buckets: FeatureBucketer{}}
+	for i := 0; i < 5; i++ {
+		for playerID := 0; playerID < 4; playerID++ {
+			strat.CFR(playerID, &state, []float64{1.0, 1.0, 1.0, 1.0})
+		}
+	}
+
+	if _, exists := strat.averageStrategy(strat.infoSetKey(&state)); !exists {
+		t.Error("The bid wasn't trained under its bucket key")
+	}
+	if _, exists := strat.averageStrategy(hashKey(state.GetInfoSetKey())); exists {
+		t.Error("The bid was trained under the hand's own key")
+	}
+	if _, trained := strat.storedPolicy(&state); !trained {
+		t.Error("The strategy has no policy for the bid")
+	}
+}
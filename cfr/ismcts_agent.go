@@ -0,0 +1,167 @@
+package cfr
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// ISMCTSAgent plays by single-observer Information Set Monte Carlo Tree
+// Search. Every iteration samples a world consistent with the agent's info
+// set and walks one shared tree of action sequences with it, choosing among
+// the actions available in that world by UCB, then finishes with a random
+// rollout. Without a WorldSampler the state must implement Sampler.
+type ISMCTSAgent struct {
+	// Iterations is the number of searches per decision, used when
+	// TimeBudget is zero
+	Iterations int
+	// TimeBudget keeps searching until it expires. One of the two must be
+	// positive.
+	TimeBudget time.Duration
+	// Exploration is the UCB constant, in units of utility
+	Exploration float64
+	// Worlds draws the world of each iteration, uniformly when nil
+	Worlds WorldSampler
+}
+
+func (agent ISMCTSAgent) EndGame() {
+
+}
+
+// ismctsNode is reached by one action from its parent. Rewards are kept
+// from the point of view of the player who took that action.
+type ismctsNode struct {
+	children  map[Action]*ismctsNode
+	player    int
+	visits    float64
+	reward    float64
+	available float64
+}
+
+func newISMCTSNode(player int) *ismctsNode {
+	return &ismctsNode{
+		children: make(map[Action]*ismctsNode),
+		player:   player,
+	}
+}
+
+// Act returns the most visited action at the root of the search
+func (agent ISMCTSAgent) Act(state State) Action {
+	validActions := state.ValidActions()
+	if len(validActions) == 1 {
+		return validActions[0]
+	}
+
+	if agent.TimeBudget <= 0 && agent.Iterations <= 0 {
+		panic("ISMCTSAgent needs a positive Iterations or TimeBudget")
+	}
+
+	root := newISMCTSNode(-1)
+	deadline := time.Now().Add(agent.TimeBudget)
+	for i := 0; agent.keepSearching(i, deadline); i++ {
+		world, err := sampleWorld(agent.Worlds, state)
+		if err != nil {
+			continue
+		}
+		agent.search(root, world)
+	}
+
+	bestAction := validActions[0]
+	bestVisits := -1.0
+	for _, action := range validActions {
+		if child, exists := root.children[action]; exists && child.visits > bestVisits {
+			bestAction = action
+			bestVisits = child.visits
+		}
+	}
+	return bestAction
+}
+
+func (agent ISMCTSAgent) keepSearching(iteration int, deadline time.Time) bool {
+	if agent.TimeBudget > 0 {
+		return time.Now().Before(deadline)
+	}
+	return iteration < agent.Iterations
+}
+
+// search runs one iteration of selection, expansion, rollout and
+// backpropagation on a sampled world
+func (agent ISMCTSAgent) search(root *ismctsNode, world State) {
+	node := root
+	path := []*ismctsNode{root}
+
+	// Select down the tree until a new node is expanded
+	for !world.IsTerminal() {
+		if world.IsChanceNode() {
+			outcome := SampleChanceOutcome(world)
+			child, exists := node.children[outcome]
+			if !exists {
+				child = newISMCTSNode(-1)
+				node.children[outcome] = child
+			}
+			world = world.TakeAction(outcome)
+			node = child
+			path = append(path, node)
+			continue
+		}
+
+		actions := world.ValidActions()
+		unexplored := make([]Action, 0, len(actions))
+		for _, action := range actions {
+			if child, exists := node.children[action]; exists {
+				child.available++
+			} else {
+				unexplored = append(unexplored, action)
+			}
+		}
+
+		if len(unexplored) > 0 {
+			action := unexplored[rand.Intn(len(unexplored))]
+			child := newISMCTSNode(world.GetCurrentAgent())
+			child.available = 1
+			node.children[action] = child
+			world = world.TakeAction(action)
+			path = append(path, child)
+			break
+		}
+
+		action := agent.selectUCB(node, actions)
+		world = world.TakeAction(action)
+		node = node.children[action]
+		path = append(path, node)
+	}
+
+	// Finish the hand with random play
+	for !world.IsTerminal() {
+		if world.IsChanceNode() {
+			world = world.TakeAction(SampleChanceOutcome(world))
+			continue
+		}
+		actions := world.ValidActions()
+		world = world.TakeAction(actions[rand.Intn(len(actions))])
+	}
+
+	for _, visited := range path {
+		visited.visits++
+		if visited.player >= 0 {
+			visited.reward += world.GetUtility(visited.player)
+		}
+	}
+}
+
+// selectUCB picks among the actions available in this world, scaling the
+// exploration term by how often each action was available rather than by
+// the parent's visits
+func (agent ISMCTSAgent) selectUCB(node *ismctsNode, actions []Action) Action {
+	bestAction := actions[0]
+	bestScore := math.Inf(-1)
+	for _, action := range actions {
+		child := node.children[action]
+		score := child.reward/child.visits + agent.Exploration*math.Sqrt(math.Log(child.available)/child.visits)
+		if score > bestScore {
+			bestAction = action
+			bestScore = score
+		}
+	}
+	return bestAction
+}
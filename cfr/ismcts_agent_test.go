@@ -0,0 +1,24 @@
+package cfr
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestISMCTSAgentRequiresABudget(t *testing.T) {
+	state := playedInto(rand.New(rand.NewSource(1)), 0)
+	defer func() {
+		if recover() == nil {
+			t.Error("Acting without a budget didn't panic")
+		}
+	}()
+	ISMCTSAgent{Exploration: 1.4}.Act(&state)
+}
+
+func TestISMCTSAgentPlaysAValidAction(t *testing.T) {
+	state := playedInto(rand.New(rand.NewSource(1)), 0)
+	action := ISMCTSAgent{Iterations: 50, Exploration: 1.4}.Act(&state)
+	if !isValid(&state, action) {
+		t.Errorf("Played %v, which isn't valid", action)
+	}
+}
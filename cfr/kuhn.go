@@ -0,0 +1,136 @@
+package cfr
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Kuhn poker actions
+const (
+	KUHN_PASS = Action(0)
+	KUHN_BET  = Action(1)
+)
+
+// KuhnState stores a hand of two player Kuhn poker. The three card deck
+// is dealt by chance actions at the start of the hand.
+type KuhnState struct {
+	cards   []int
+	history string
+}
+
+// NewKuhnState returns a hand before the cards are dealt
+func NewKuhnState() KuhnState {
+	return KuhnState{
+		cards: make([]int, 0, 2),
+	}
+}
+
+// IsChanceNode ...
+func (state *KuhnState) IsChanceNode() bool {
+	return len(state.cards) < 2
+}
+
+// ChanceOutcomes deals one of the cards not yet dealt
+func (state *KuhnState) ChanceOutcomes() ([]Action, []float64) {
+	outcomes := make([]Action, 0, 3)
+	for card := 1; card <= 3; card++ {
+		if len(state.cards) == 0 || state.cards[0] != card {
+			outcomes = append(outcomes, Action(card))
+		}
+	}
+
+	probs := make([]float64, len(outcomes))
+	for i := range probs {
+		probs[i] = 1.0 / float64(len(outcomes))
+	}
+	return outcomes, probs
+}
+
+// SampleWorld deals the opponent one of the two cards the current player
+// doesn't hold
+func (state *KuhnState) SampleWorld() (State, error) {
+	world := KuhnState{
+		cards:   []int{state.cards[0], state.cards[1]},
+		history: state.history,
+	}
+
+	player := state.GetCurrentAgent()
+	others := make([]int, 0, 2)
+	for card := 1; card <= 3; card++ {
+		if card != state.cards[player] {
+			others = append(others, card)
+		}
+	}
+	world.cards[1-player] = others[rand.Intn(len(others))]
+	return &world, nil
+}
+
+// ValidActions ...
+func (state *KuhnState) ValidActions() []Action {
+	return []Action{KUHN_PASS, KUHN_BET}
+}
+
+// TakeAction ...
+func (state *KuhnState) TakeAction(action Action) State {
+	if state.IsChanceNode() {
+		state.cards = append(state.cards, int(action))
+	} else if action == KUHN_BET {
+		state.history += "b"
+	} else {
+		state.history += "p"
+	}
+
+	return State(state)
+}
+
+// TakeActionCopy ...
+func (state KuhnState) TakeActionCopy(action Action) State {
+	clone := state
+	clone.cards = make([]int, len(state.cards), 2)
+	copy(clone.cards, state.cards)
+	return clone.TakeAction(action)
+}
+
+// IsTerminal ...
+func (state *KuhnState) IsTerminal() bool {
+	switch state.history {
+	case "pp", "bp", "bb", "pbp", "pbb":
+		return true
+	}
+	return false
+}
+
+// GetCurrentAgent ...
+func (state *KuhnState) GetCurrentAgent() int {
+	return len(state.history) % 2
+}
+
+// GetUtility ...
+func (state *KuhnState) GetUtility(playerID int) float64 {
+	// Winnings for player 0
+	var winnings float64
+	showdown := 1.0
+	if state.cards[1] > state.cards[0] {
+		showdown = -1.0
+	}
+	switch state.history {
+	case "pp":
+		winnings = showdown
+	case "bb", "pbb":
+		winnings = 2 * showdown
+	case "bp":
+		winnings = 1
+	case "pbp":
+		winnings = -1
+	}
+
+	if playerID == 0 {
+		return winnings
+	}
+	return -winnings
+}
+
+// GetInfoSetKey ...
+func (state *KuhnState) GetInfoSetKey() InfoSetKey {
+	return InfoSetKey(fmt.Sprintf("%d%s", state.cards[state.GetCurrentAgent()], state.history))
+}
@@ -0,0 +1,150 @@
+package cfr
+
+import "math"
+
+// LocalBestResponse estimates how exploitable a Euchre strategy is when an
+// exact best response is out of reach. One seat is taken over by an agent
+// that, at each of its decisions, samples worlds consistent with its info
+// set and picks the action with the best average rollout value while every
+// other seat plays the strategy. The gain of that agent over the strategy
+// is a lower bound on the exploitability.
+type LocalBestResponse struct {
+	Strat *Strategy
+	// Worlds sampled from the info set at every decision
+	NumWorlds int
+	// Rollouts of the strategy per world and action
+	NumRollouts int
+	// Worlds draws the worlds, uniformly when nil. A BeliefSampler modeling
+	// the strategy makes the response use what the strategy's plays reveal.
+	Worlds WorldSampler
+	// KeyMode must match the keys the strategy was trained with
+	KeyMode InfoSetKeyMode
+}
+
+// LBRReport holds the means of an evaluation along with the half width of
+// their 95% confidence intervals
+type LBRReport struct {
+	Games int
+	// Utility of the local best response seat
+	Value   float64
+	ValueCI float64
+	// Utility of the strategy itself in the same seats on the same deals
+	Baseline   float64
+	BaselineCI float64
+	// Per deal difference between the two, the lower bound on exploitability
+	Gain   float64
+	GainCI float64
+}
+
+// Evaluate plays numGames deals with the local best response rotating
+// through the seats, then replays each deal with the strategy in that seat
+func (lbr *LocalBestResponse) Evaluate(numGames int) LBRReport {
+	var value, baseline, gain runningMean
+	for i := 0; i < numGames; i++ {
+		seat := i % 4
+		state := NewEuchreState()
+		state.KeyMode = lbr.KeyMode
+		baselineState := state.Clone()
+
+		game := Game{GameState: &state, Agents: make([]Agent, 4)}
+		for p := range game.Agents {
+			game.Agents[p] = StrategyAgent{Strat: lbr.Strat}
+		}
+		game.Agents[seat] = lbrAgent{lbr: lbr}
+		lbrUtility := game.Play()[seat]
+
+		game.GameState = &baselineState
+		game.Agents[seat] = StrategyAgent{Strat: lbr.Strat}
+		baselineUtility := game.Play()[seat]
+
+		value.add(lbrUtility)
+		baseline.add(baselineUtility)
+		gain.add(lbrUtility - baselineUtility)
+	}
+
+	return LBRReport{
+		Games:      numGames,
+		Value:      value.mean(),
+		ValueCI:    value.confidence95(),
+		Baseline:   baseline.mean(),
+		BaselineCI: baseline.confidence95(),
+		Gain:       gain.mean(),
+		GainCI:     gain.confidence95(),
+	}
+}
+
+// lbrAgent is the seat played by the local best response
+type lbrAgent struct {
+	lbr *LocalBestResponse
+}
+
+func (agent lbrAgent) EndGame() {
+
+}
+
+// Act picks the action with the highest average rollout value over worlds
+// sampled from the info set
+func (agent lbrAgent) Act(state State) Action {
+	validActions := state.ValidActions()
+	if len(validActions) == 1 {
+		return validActions[0]
+	}
+
+	playerID := state.GetCurrentAgent()
+	values := make([]float64, len(validActions))
+	for w := 0; w < agent.lbr.NumWorlds; w++ {
+		world, err := sampleWorld(agent.lbr.Worlds, state)
+		if err != nil {
+			continue
+		}
+
+		for i, action := range validActions {
+			for r := 0; r < agent.lbr.NumRollouts; r++ {
+				values[i] += agent.lbr.rollout(world.TakeActionCopy(action), playerID)
+			}
+		}
+	}
+
+	bestIdx := 0
+	for i := range values {
+		if values[i] > values[bestIdx] {
+			bestIdx = i
+		}
+	}
+	return validActions[bestIdx]
+}
+
+// rollout plays every seat with the strategy until the hand ends
+func (lbr *LocalBestResponse) rollout(state State, playerID int) float64 {
+	return playOut(state, lbr.Strat, playerID)
+}
+
+// runningMean accumulates samples for a mean and its confidence interval
+type runningMean struct {
+	count int
+	sum   float64
+	sumSq float64
+}
+
+func (m *runningMean) add(sample float64) {
+	m.count++
+	m.sum += sample
+	m.sumSq += sample * sample
+}
+
+func (m *runningMean) mean() float64 {
+	if m.count == 0 {
+		return 0
+	}
+	return m.sum / float64(m.count)
+}
+
+// confidence95 returns the half width of the normal 95% confidence interval
+func (m *runningMean) confidence95() float64 {
+	if m.count < 2 {
+		return math.Inf(1)
+	}
+	mean := m.mean()
+	variance := (m.sumSq - float64(m.count)*mean*mean) / float64(m.count-1)
+	return 1.96 * math.Sqrt(math.Max(variance, 0)/float64(m.count))
+}
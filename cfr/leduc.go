@@ -0,0 +1,199 @@
+package cfr
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Leduc poker actions
+const (
+	LEDUC_FOLD  = Action(0)
+	LEDUC_CALL  = Action(1)
+	LEDUC_RAISE = Action(2)
+)
+
+// leducRaiseSizes is the fixed raise amount in each betting round
+var leducRaiseSizes = [2]int{2, 4}
+
+// LeducState stores a hand of two player Leduc poker. The deck holds two
+// suits of Jack, Queen and King. Each player is dealt a private card, a
+// betting round follows, then a public card is turned for a second round.
+// At most two raises are allowed per round.
+type LeducState struct {
+	// Cards 0-5 in deal order: player 0, player 1, then the public card.
+	// A card's rank is card/2.
+	cards   []int
+	history string
+	bets    [2]int
+
+	round        int
+	raises       int
+	roundActions int
+	currentAgent int
+	folded       bool
+}
+
+// NewLeducState returns a hand with the antes posted and no cards dealt
+func NewLeducState() LeducState {
+	return LeducState{
+		cards: make([]int, 0, 3),
+		bets:  [2]int{1, 1},
+	}
+}
+
+// IsChanceNode ...
+func (state *LeducState) IsChanceNode() bool {
+	return len(state.cards) < 2 || (state.round == 1 && len(state.cards) < 3)
+}
+
+// ChanceOutcomes deals one of the cards not yet dealt
+func (state *LeducState) ChanceOutcomes() ([]Action, []float64) {
+	outcomes := make([]Action, 0, 6)
+	for card := 0; card < 6; card++ {
+		dealt := false
+		for _, other := range state.cards {
+			if other == card {
+				dealt = true
+			}
+		}
+		if !dealt {
+			outcomes = append(outcomes, Action(card))
+		}
+	}
+
+	probs := make([]float64, len(outcomes))
+	for i := range probs {
+		probs[i] = 1.0 / float64(len(outcomes))
+	}
+	return outcomes, probs
+}
+
+// SampleWorld deals the opponent a private card from the cards the current
+// player hasn't seen
+func (state *LeducState) SampleWorld() (State, error) {
+	world := *state
+	world.cards = make([]int, len(state.cards), 3)
+	copy(world.cards, state.cards)
+
+	player := state.currentAgent
+	unseen := make([]int, 0, 5)
+	for card := 0; card < 6; card++ {
+		seen := card == state.cards[player] || (len(state.cards) == 3 && card == state.cards[2])
+		if !seen {
+			unseen = append(unseen, card)
+		}
+	}
+	world.cards[1-player] = unseen[rand.Intn(len(unseen))]
+	return &world, nil
+}
+
+// ValidActions ...
+func (state *LeducState) ValidActions() []Action {
+	actions := make([]Action, 0, 3)
+	if state.bets[0] != state.bets[1] {
+		actions = append(actions, LEDUC_FOLD)
+	}
+	actions = append(actions, LEDUC_CALL)
+	if state.raises < 2 {
+		actions = append(actions, LEDUC_RAISE)
+	}
+	return actions
+}
+
+// TakeAction ...
+func (state *LeducState) TakeAction(action Action) State {
+	if state.IsChanceNode() {
+		state.cards = append(state.cards, int(action))
+		return State(state)
+	}
+
+	player := state.currentAgent
+	opponent := 1 - player
+	state.roundActions++
+	switch action {
+	case LEDUC_FOLD:
+		state.history += "f"
+		state.folded = true
+		return State(state)
+	case LEDUC_CALL:
+		state.history += "c"
+		state.bets[player] = state.bets[opponent]
+		// A check opening the round passes the action, any other call
+		// closes the round
+		if state.roundActions > 1 {
+			state.round++
+			state.raises = 0
+			state.roundActions = 0
+			state.currentAgent = 0
+			if state.round == 1 {
+				state.history += "/"
+			}
+			return State(state)
+		}
+	case LEDUC_RAISE:
+		state.history += "r"
+		state.bets[player] = state.bets[opponent] + leducRaiseSizes[state.round]
+		state.raises++
+	}
+
+	state.currentAgent = opponent
+	return State(state)
+}
+
+// TakeActionCopy ...
+func (state LeducState) TakeActionCopy(action Action) State {
+	clone := state
+	clone.cards = make([]int, len(state.cards), 3)
+	copy(clone.cards, state.cards)
+	return clone.TakeAction(action)
+}
+
+// IsTerminal ...
+func (state *LeducState) IsTerminal() bool {
+	return state.folded || state.round == 2
+}
+
+// GetCurrentAgent ...
+func (state *LeducState) GetCurrentAgent() int {
+	return state.currentAgent
+}
+
+// GetUtility ...
+func (state *LeducState) GetUtility(playerID int) float64 {
+	opponent := 1 - playerID
+	if state.folded {
+		// The folding player keeps the action
+		if state.currentAgent == playerID {
+			return -float64(state.bets[playerID])
+		}
+		return float64(state.bets[opponent])
+	}
+
+	playerStrength := state.handStrength(playerID)
+	opponentStrength := state.handStrength(opponent)
+	if playerStrength > opponentStrength {
+		return float64(state.bets[opponent])
+	} else if playerStrength < opponentStrength {
+		return -float64(state.bets[playerID])
+	}
+	return 0
+}
+
+// handStrength ranks a player's private card, with pairing the public
+// card beating any unpaired hand
+func (state *LeducState) handStrength(playerID int) int {
+	rank := state.cards[playerID] / 2
+	if rank == state.cards[2]/2 {
+		return 10 + rank
+	}
+	return rank
+}
+
+// GetInfoSetKey ...
+func (state *LeducState) GetInfoSetKey() InfoSetKey {
+	public := "-"
+	if len(state.cards) == 3 {
+		public = fmt.Sprintf("%d", state.cards[2]/2)
+	}
+	return InfoSetKey(fmt.Sprintf("%d%s:%s", state.cards[state.currentAgent]/2, public, state.history))
+}
@@ -6,6 +6,8 @@ import (
 
 // OptimalAgent ...
 type OptimalAgent struct {
+	// Endgame, if set, answers late positions without searching them
+	Endgame *EndgameTable
 }
 
 func (agent OptimalAgent) EndGame() {
@@ -15,40 +17,168 @@ func (agent OptimalAgent) EndGame() {
 // Act calculates the best action for an optimal player who
 // can observe all cards/outcomes
 func (agent OptimalAgent) Act(state State) Action {
-	_, action := minimax(state, state.GetCurrentAgent())
+	_, action := minimax(state, state.GetCurrentAgent(), agent.Endgame)
 	return action
 }
 
-func minimax(state State, maximizingPlayer int) (float64, Action) {
+// MoveOrderer is implemented by states that can sort their valid actions
+// so the strongest are searched first, which lets alpha-beta cut more
+type MoveOrderer interface {
+	OrderedActions() []Action
+}
+
+// PositionKey identifies a position for the transposition table
+type PositionKey [4]uint64
+
+// Transposer is implemented by states that can name positions which play
+// out the same no matter how they were reached. The key only needs to be
+// unique among positions reachable within a single search.
+type Transposer interface {
+	TranspositionKey() PositionKey
+}
+
+// Bounds stored with transposition table values
+const (
+	exactBound = iota
+	lowerBound
+	upperBound
+)
+
+type transposition struct {
+	value  float64
+	bound  int
+	action Action
+}
+
+// minimaxSearch runs alpha-beta searches for one maximizing player. Team
+// mates of the maximizing player maximize, the other team minimizes. The
+// transposition table is only valid for the positions of a single deal.
+type minimaxSearch struct {
+	maximizingPlayer int
+	table            map[PositionKey]transposition
+	endgame          *EndgameTable
+}
+
+func newMinimaxSearch(maximizingPlayer int, endgame *EndgameTable) *minimaxSearch {
+	return &minimaxSearch{
+		maximizingPlayer: maximizingPlayer,
+		table:            make(map[PositionKey]transposition),
+		endgame:          endgame,
+	}
+}
+
+func minimax(state State, maximizingPlayer int, endgame *EndgameTable) (float64, Action) {
+	return newMinimaxSearch(maximizingPlayer, endgame).value(state, math.Inf(-1), math.Inf(1))
+}
+
+// child returns the value of a position below the one being searched. Only
+// its value is needed, so the endgame table can stand in for the search.
+func (search *minimaxSearch) child(state State, alpha float64, beta float64) float64 {
+	if search.endgame != nil {
+		if prober, ok := state.(EndgameProber); ok {
+			if value, found := prober.ProbeEndgame(search.endgame, search.maximizingPlayer); found {
+				return value
+			}
+		}
+	}
+
+	value, _ := search.value(state, alpha, beta)
+	return value
+}
+
+// value returns the exact minimax value when it lies inside (alpha, beta),
+// otherwise a bound on the side of the window it fell on
+func (search *minimaxSearch) value(state State, alpha float64, beta float64) (float64, Action) {
 	if state.IsTerminal() {
-		return state.GetUtility(maximizingPlayer), Action(0)
+		return state.GetUtility(search.maximizingPlayer), Action(0)
+	}
+
+	if state.IsChanceNode() {
+		outcomes, probs := state.ChanceOutcomes()
+		value := 0.0
+		for i, outcome := range outcomes {
+			outcomeValue := search.child(applyAction(state, outcome), math.Inf(-1), math.Inf(1))
+			undoAction(state, outcome)
+			value += probs[i] * outcomeValue
+		}
+		return value, Action(0)
+	}
+
+	transposer, hasKey := state.(Transposer)
+	var key PositionKey
+	var hashAction Action
+	hasHashAction := false
+	if hasKey {
+		key = transposer.TranspositionKey()
+		if entry, exists := search.table[key]; exists {
+			if entry.bound == exactBound ||
+				(entry.bound == lowerBound && entry.value >= beta) ||
+				(entry.bound == upperBound && entry.value <= alpha) {
+				return entry.value, entry.action
+			}
+			hashAction = entry.action
+			hasHashAction = true
+		}
+	}
+
+	var actions []Action
+	if orderer, ok := state.(MoveOrderer); ok {
+		actions = orderer.OrderedActions()
+	} else {
+		actions = state.ValidActions()
+	}
+	// Try the best action from an earlier search of this position first
+	if hasHashAction {
+		for i, action := range actions {
+			if action == hashAction {
+				copy(actions[1:i+1], actions[:i])
+				actions[0] = hashAction
+				break
+			}
+		}
 	}
 
 	currentTeam := state.GetCurrentAgent() % 2
-	maxTeam := maximizingPlayer % 2
+	maxTeam := search.maximizingPlayer % 2
+	bestAction := actions[0]
+	var value float64
 	if currentTeam == maxTeam {
-		value := math.Inf(-1)
-		bestAction := Action(0)
-		for _, action := range state.ValidActions() {
-			actionValue, _ := minimax(state.TakeActionCopy(action), maximizingPlayer)
+		value = math.Inf(-1)
+		for _, action := range actions {
+			actionValue := search.child(applyAction(state, action), math.Max(alpha, value), beta)
+			undoAction(state, action)
 			if actionValue > value {
 				value = actionValue
 				bestAction = action
 			}
+			if value >= beta {
+				break
+			}
 		}
-
-		return value, bestAction
 	} else {
-		value := math.Inf(1)
-		worstAction := Action(0)
-		for _, action := range state.ValidActions() {
-			actionValue, _ := minimax(state.TakeActionCopy(action), maximizingPlayer)
+		value = math.Inf(1)
+		for _, action := range actions {
+			actionValue := search.child(applyAction(state, action), alpha, math.Min(beta, value))
+			undoAction(state, action)
 			if actionValue < value {
 				value = actionValue
-				worstAction = action
+				bestAction = action
+			}
+			if value <= alpha {
+				break
 			}
 		}
+	}
 
-		return value, worstAction
+	if hasKey {
+		bound := exactBound
+		if value <= alpha {
+			bound = upperBound
+		} else if value >= beta {
+			bound = lowerBound
+		}
+		search.table[key] = transposition{value: value, bound: bound, action: bestAction}
 	}
+
+	return value, bestAction
 }
@@ -0,0 +1,49 @@
+package cfr
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// plainMinimax searches every line without pruning, ordering or a
+// transposition table
+func plainMinimax(state State, maximizingPlayer int) float64 {
+	if state.IsTerminal() {
+		return state.GetUtility(maximizingPlayer)
+	}
+
+	maximizing := state.GetCurrentAgent()%2 == maximizingPlayer%2
+	best := math.Inf(1)
+	if maximizing {
+		best = math.Inf(-1)
+	}
+	for _, action := range state.ValidActions() {
+		value := plainMinimax(state.TakeActionCopy(action), maximizingPlayer)
+		if (maximizing && value > best) || (!maximizing && value < best) {
+			best = value
+		}
+	}
+	return best
+}
+
+func TestMinimaxMatchesPlainMinimax(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for game := 0; game < 200; game++ {
+		state := playedInto(r, 8+r.Intn(8))
+		if state.IsTerminal() {
+			continue
+		}
+		player := state.GetCurrentAgent()
+		value, action := minimax(&state, player, nil)
+		if expected := plainMinimax(&state, player); value != expected {
+			t.Fatalf("Alpha-beta found %v, plain minimax %v", value, expected)
+		}
+		if !isValid(&state, action) {
+			t.Fatalf("Alpha-beta chose %v, which isn't valid", action)
+		}
+		if after := plainMinimax(state.TakeActionCopy(action), player); after != value {
+			t.Fatalf("Alpha-beta chose an action worth %v instead of %v", after, value)
+		}
+	}
+}
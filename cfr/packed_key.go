@@ -0,0 +1,56 @@
+package cfr
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+)
+
+// PackedKey is a fixed width info set key, the form a Strategy stores its
+// info sets under. Abstractions that implement PackedKeyer fill the low 127
+// bits with the info set itself. Every other key is hashed into a PackedKey
+// with the top bit set, so the two kinds never meet.
+type PackedKey [2]uint64
+
+// PackedKeyer is implemented by abstractions that can build a PackedKey
+// straight from a state without formatting an InfoSetKey first
+type PackedKeyer interface {
+	// PackedInfoSetKey returns the packed key of the info set containing
+	// state, or false when it has none
+	PackedInfoSetKey(state State) (PackedKey, bool)
+}
+
+// hashedKeyBit marks a PackedKey hashed from an InfoSetKey
+const hashedKeyBit = uint64(1) << 63
+
+// hashKey hashes an InfoSetKey with 128 bit FNV-1a. Collisions are
+// possible in principle but vanishingly rare at any number of info sets a
+// Strategy could hold.
+func hashKey(key InfoSetKey) PackedKey {
+	hash := fnv.New128a()
+	hash.Write([]byte(key))
+	sum := hash.Sum(nil)
+	return PackedKey{
+		binary.BigEndian.Uint64(sum[8:]),
+		binary.BigEndian.Uint64(sum[:8]) | hashedKeyBit,
+	}
+}
+
+// keyPacker appends fields to a PackedKey from the lowest bit up
+type keyPacker struct {
+	key  PackedKey
+	bits uint
+}
+
+// put appends the low width bits of value
+func (packer *keyPacker) put(value uint64, width uint) {
+	if packer.bits+width > 127 {
+		panic("Packed key is out of room")
+	}
+	value &= (uint64(1) << width) - 1
+	word, offset := packer.bits/64, packer.bits%64
+	packer.key[word] |= value << offset
+	if offset+width > 64 {
+		packer.key[word+1] |= value >> (64 - offset)
+	}
+	packer.bits += width
+}
@@ -0,0 +1,102 @@
+package cfr
+
+import (
+	"fmt"
+	"math"
+)
+
+// sampleAttempts is how many draws PIMCAgent makes per world it needs
+// before giving up on a WorldSampler
+const sampleAttempts = 10
+
+// PIMCAgent plays by Perfect Information Monte Carlo. It samples worlds
+// consistent with its info set, solves each one with minimax as if every
+// card were visible, and plays the action with the best average value.
+// Unlike OptimalAgent it never looks at the hidden cards of the real state.
+type PIMCAgent struct {
+	NumWorlds int
+	// Endgame, if set, answers late positions without searching them
+	Endgame *EndgameTable
+	// Worlds draws the worlds to solve, uniformly when nil
+	Worlds WorldSampler
+}
+
+func (agent PIMCAgent) EndGame() {
+
+}
+
+// Act averages the minimax value of each action over sampled worlds. Without
+// a WorldSampler the state must implement Sampler. It panics when no world
+// can be drawn at all, since even uniform sampling only fails for a state
+// no deal is consistent with.
+func (agent PIMCAgent) Act(state State) Action {
+	validActions := state.ValidActions()
+	if len(validActions) == 1 {
+		return validActions[0]
+	}
+	if agent.NumWorlds < 1 {
+		panic("PIMCAgent needs a positive NumWorlds")
+	}
+
+	worlds, err := agent.worlds(state)
+	if len(worlds) == 0 {
+		panic(fmt.Sprintf("PIMCAgent couldn't sample a world: %v", err))
+	}
+
+	playerID := state.GetCurrentAgent()
+	values := make([]float64, len(validActions))
+	for _, world := range worlds {
+		// Every action is searched with a full window for its exact value,
+		// sharing the transposition table within the world
+		search := newMinimaxSearch(playerID, agent.Endgame)
+		for i, action := range validActions {
+			values[i] += search.child(world.TakeActionCopy(action), math.Inf(-1), math.Inf(1))
+		}
+	}
+
+	bestIdx := 0
+	for i := range values {
+		if values[i] > values[bestIdx] {
+			bestIdx = i
+		}
+	}
+	return validActions[bestIdx]
+}
+
+// worlds samples the worlds to solve. When sampling uniformly from a state
+// with no more than NumWorlds consistent worlds, each is solved once
+// instead. Failed draws are retried, and when Worlds can't draw any world
+// they are drawn uniformly instead. The last error is returned with them.
+func (agent PIMCAgent) worlds(state State) ([]State, error) {
+	if euchreState, ok := state.(*EuchreState); ok && agent.Worlds == nil {
+		if all, ok := euchreState.EnumerateWorlds(agent.NumWorlds); ok {
+			worlds := make([]State, len(all))
+			for i := range all {
+				worlds[i] = &all[i]
+			}
+			return worlds, nil
+		}
+	}
+
+	worlds, err := agent.sample(agent.Worlds, state)
+	if len(worlds) == 0 && agent.Worlds != nil {
+		worlds, err = agent.sample(nil, state)
+	}
+	return worlds, err
+}
+
+// sample draws NumWorlds worlds with sampler, making up to sampleAttempts
+// draws for each
+func (agent PIMCAgent) sample(sampler WorldSampler, state State) ([]State, error) {
+	worlds := make([]State, 0, agent.NumWorlds)
+	var err error
+	for attempt := 0; len(worlds) < agent.NumWorlds && attempt < sampleAttempts*agent.NumWorlds; attempt++ {
+		var world State
+		world, err = sampleWorld(sampler, state)
+		if err != nil {
+			continue
+		}
+		worlds = append(worlds, world)
+	}
+	return worlds, err
+}
@@ -0,0 +1,68 @@
+package cfr
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+// flakyWorlds fails all but every succeedEvery-th draw of a world, or
+// every draw when succeedEvery is 0
+type flakyWorlds struct {
+	*EuchreState
+	succeedEvery int
+	draws        int
+}
+
+func (state *flakyWorlds) SampleWorld() (State, error) {
+	state.draws++
+	if state.succeedEvery == 0 || state.draws%state.succeedEvery != 0 {
+		return nil, errors.New("No world")
+	}
+	return state.EuchreState.SampleWorld()
+}
+
+// pimcState is a late position with a choice to make, so solving its
+// worlds is quick
+func pimcState() EuchreState {
+	r := rand.New(rand.NewSource(1))
+	for {
+		if state := playedInto(r, 12); len(state.ValidActions()) > 1 {
+			return state
+		}
+	}
+}
+
+func TestPIMCAgentPlaysAValidAction(t *testing.T) {
+	state := pimcState()
+	if action := (PIMCAgent{NumWorlds: 5}).Act(&state); !isValid(&state, action) {
+		t.Errorf("Played %v, which isn't valid", action)
+	}
+}
+
+func TestPIMCAgentRetriesFailedWorlds(t *testing.T) {
+	state := pimcState()
+	flaky := flakyWorlds{EuchreState: &state, succeedEvery: 3}
+	agent := PIMCAgent{NumWorlds: 4}
+	if worlds, _ := agent.worlds(&flaky); len(worlds) != agent.NumWorlds {
+		t.Fatalf("Sampled %d worlds, wanted %d", len(worlds), agent.NumWorlds)
+	}
+	if action := agent.Act(&flaky); !isValid(&state, action) {
+		t.Errorf("Played %v, which isn't valid", action)
+	}
+}
+
+func TestPIMCAgentReportsWhenNoWorldSamples(t *testing.T) {
+	for name, agent := range map[string]PIMCAgent{"failing": {NumWorlds: 3}, "no worlds": {}} {
+		state := pimcState()
+		flaky := flakyWorlds{EuchreState: &state}
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Acting with %s worlds didn't panic", name)
+				}
+			}()
+			agent.Act(&flaky)
+		}()
+	}
+}
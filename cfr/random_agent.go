@@ -18,3 +18,13 @@ func (agent RandomAgent) Act(state State) Action {
 	actionIndex := rand.Intn(len(actions))
 	return actions[actionIndex]
 }
+
+// Policy implements PolicyModel
+func (agent RandomAgent) Policy(state State) map[Action]float64 {
+	actions := state.ValidActions()
+	policy := make(map[Action]float64, len(actions))
+	for _, action := range actions {
+		policy[action] = 1.0 / float64(len(actions))
+	}
+	return policy
+}
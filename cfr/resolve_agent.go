@@ -0,0 +1,359 @@
+package cfr
+
+import (
+	"errors"
+	"strconv"
+)
+
+// Gadget actions taken by the opponents at the root of a re-solved subgame
+const (
+	GADGET_FOLLOW    = Action(0)
+	GADGET_TERMINATE = Action(1)
+)
+
+// ResolveAgent plays a blueprint strategy and refines it at every decision
+// with safe subgame re-solving. Worlds consistent with the agent's info set
+// are sampled and weighted by how likely the other seats were to play the
+// history under the blueprint. The subgame from those worlds is solved with
+// CFR behind a gadget that lets the opponents fall back on what they would
+// have earned against the blueprint, so the refined strategy can't do worse
+// against them than the blueprint did. That guarantee is exact for two
+// player zero-sum games; with two teams it treats each team as one player.
+type ResolveAgent struct {
+	Blueprint *Strategy
+	// Worlds sampled for each subgame
+	NumWorlds int
+	// CFR iterations spent re-solving each subgame
+	NumIterations int
+	// MaxMargin uses the max-margin gadget instead of the resolve gadget
+	MaxMargin bool
+	// AltValue estimates what the opponents earn from a world against the
+	// blueprint
+	AltValue ValueFunction
+	// LeafValue, if set, limits the subgame to Depth tricks and scores the
+	// positions there with it
+	LeafValue ValueFunction
+	Depth     int
+	// FallbackErr says why the latest Act played the blueprint instead of
+	// a re-solved policy. It is nil when the subgame was re-solved.
+	FallbackErr error
+}
+
+// NewResolveAgent returns an agent that re-solves one trick deep, scoring
+// the gadget and the leaves with rollouts of the blueprint
+func NewResolveAgent(blueprint *Strategy) *ResolveAgent {
+	return &ResolveAgent{
+		Blueprint:     blueprint,
+		NumWorlds:     10,
+		NumIterations: 10,
+		AltValue:      RolloutValue{Strat: blueprint, NumRollouts: 4},
+		LeafValue:     RolloutValue{Strat: blueprint, NumRollouts: 1},
+		Depth:         1,
+	}
+}
+
+func (agent *ResolveAgent) EndGame() {
+
+}
+
+// Act re-solves the subgame rooted at the agent's info set and samples an
+// action from the refined average strategy. When the subgame can't be
+// re-solved the blueprint is played and FallbackErr says why.
+func (agent *ResolveAgent) Act(state State) Action {
+	agent.FallbackErr = nil
+	validActions := state.ValidActions()
+	if len(validActions) == 1 {
+		return validActions[0]
+	}
+
+	gadget, err := agent.buildGadget(state.(*EuchreState))
+	var policy map[Action]float64
+	if gadget == nil {
+		agent.FallbackErr = err
+	} else {
+		subgame := NewStrategy()
+		subgame.Abstraction = agent.Blueprint.Abstraction
+		for i := 0; i < agent.NumIterations; i++ {
+			for playerID := 0; playerID < 4; playerID++ {
+				probs := []float64{1.0, 1.0, 1.0, 1.0}
+				root := &gadgetState{gadget: gadget}
+				if agent.LeafValue != nil {
+					subgame.DepthLimitedCFR(playerID, root, probs, agent.Depth, agent.LeafValue)
+				} else {
+					subgame.CFR(playerID, root, probs)
+				}
+			}
+		}
+		var exists bool
+		policy, exists = subgame.storedPolicy(state)
+		if !exists {
+			agent.FallbackErr = errors.New("Re-solving never reached the agent's info set")
+		}
+	}
+	if agent.FallbackErr != nil {
+		policy = agent.Blueprint.Policy(state)
+	}
+
+	return sampleAction(policy)
+}
+
+// buildGadget samples the worlds of the subgame and scores the opponents'
+// alternatives. Worlds the blueprint can't weigh are left out. It returns
+// nil and the reason when no sampled world is reachable under the
+// blueprint.
+func (agent *ResolveAgent) buildGadget(state *EuchreState) (*resolveGadget, error) {
+	playerID := state.GetCurrentAgent()
+	gadget := resolveGadget{
+		opponent:  (playerID + 1) % 4,
+		maxMargin: agent.MaxMargin,
+	}
+
+	groupIdx := make(map[uint64]int)
+	altSums := make([]float64, 0)
+	err := errors.New("No sampled world is reachable under the blueprint")
+	for w := 0; w < agent.NumWorlds; w++ {
+		world, sampleErr := state.SampleInfoSet()
+		if sampleErr != nil {
+			err = sampleErr
+			continue
+		}
+		weight, likelihoodErr := historyLikelihood(agent.Blueprint, &world, playerID)
+		if likelihoodErr != nil {
+			err = likelihoodErr
+			continue
+		}
+		if weight == 0 {
+			continue
+		}
+
+		// The opponents' hands are their private information at the root
+		hands := uint64(world.playerHands[gadget.opponent]) | uint64(world.playerHands[(gadget.opponent+2)%4])<<24
+		g, exists := groupIdx[hands]
+		if !exists {
+			g = len(gadget.groups)
+			groupIdx[hands] = g
+			gadget.groups = append(gadget.groups, nil)
+			gadget.groupKeys = append(gadget.groupKeys, InfoSetKey(strconv.FormatUint(hands, 16)))
+			gadget.groupWeights = append(gadget.groupWeights, 0)
+			altSums = append(altSums, 0)
+		}
+
+		gadget.groups[g] = append(gadget.groups[g], len(gadget.worlds))
+		gadget.worlds = append(gadget.worlds, world)
+		gadget.weights = append(gadget.weights, weight)
+		gadget.groupWeights[g] += weight
+		altSums[g] += weight * agent.AltValue.Value(&world, gadget.opponent)
+	}
+	if len(gadget.worlds) == 0 {
+		return nil, err
+	}
+
+	gadget.altValues = make([]float64, len(gadget.groups))
+	for g := range gadget.groups {
+		gadget.altValues[g] = altSums[g] / gadget.groupWeights[g]
+	}
+	return &gadget, nil
+}
+
+// resolveGadget holds the sampled worlds of a subgame grouped by the
+// opponents' info sets at its root
+type resolveGadget struct {
+	worlds  []EuchreState
+	weights []float64
+	// World indices, total weight, key and blueprint value for the
+	// opponents of each group
+	groups       [][]int
+	groupWeights []float64
+	groupKeys    []InfoSetKey
+	altValues    []float64
+	// opponent chooses at the gadget on behalf of the opposing team
+	opponent  int
+	maxMargin bool
+}
+
+// Phases of a gadgetState
+const (
+	gadgetRoot = iota
+	gadgetChoice
+	gadgetDeal
+	gadgetPlay
+	gadgetTerminated
+)
+
+// gadgetState is a position in a subgame behind its gadget. The resolve
+// gadget deals an opponent info set by chance and lets the opponents take
+// their blueprint value instead of playing. The max-margin gadget lets the
+// opponents pick the info set and scores play relative to the blueprint
+// value. Once a world is dealt the state plays it out.
+type gadgetState struct {
+	gadget *resolveGadget
+	phase  int
+	group  int
+	world  *EuchreState
+}
+
+// ValidActions ...
+func (state *gadgetState) ValidActions() []Action {
+	switch state.phase {
+	case gadgetRoot:
+		actions := make([]Action, len(state.gadget.groups))
+		for g := range actions {
+			actions[g] = Action(g)
+		}
+		return actions
+	case gadgetChoice:
+		return []Action{GADGET_FOLLOW, GADGET_TERMINATE}
+	case gadgetPlay:
+		return state.world.ValidActions()
+	}
+	return nil
+}
+
+// TakeAction ...
+func (state *gadgetState) TakeAction(action Action) State {
+	switch state.phase {
+	case gadgetRoot:
+		state.group = int(action)
+		if state.gadget.maxMargin {
+			state.phase = gadgetDeal
+		} else {
+			state.phase = gadgetChoice
+		}
+	case gadgetChoice:
+		if action == GADGET_TERMINATE {
+			state.phase = gadgetTerminated
+		} else {
+			state.phase = gadgetDeal
+		}
+	case gadgetDeal:
+		world := state.gadget.worlds[int(action)].Clone()
+		state.world = &world
+		state.phase = gadgetPlay
+	case gadgetPlay:
+		state.world.TakeAction(action)
+	}
+	return State(state)
+}
+
+// UndoAction implements Undoable
+func (state *gadgetState) UndoAction(action Action) {
+	switch state.phase {
+	case gadgetChoice:
+		state.phase = gadgetRoot
+	case gadgetTerminated:
+		state.phase = gadgetChoice
+	case gadgetDeal:
+		if state.gadget.maxMargin {
+			state.phase = gadgetRoot
+		} else {
+			state.phase = gadgetChoice
+		}
+	case gadgetPlay:
+		// Every world starts from the same public history, so the world is
+		// fresh from the deal when no bid or card has been made past it
+		root := &state.gadget.worlds[0]
+		if state.world.numBids+state.world.numPlayed > root.numBids+root.numPlayed {
+			state.world.UndoAction(action)
+		} else {
+			state.world = nil
+			state.phase = gadgetDeal
+		}
+	}
+}
+
+// TakeActionCopy ...
+func (state *gadgetState) TakeActionCopy(action Action) State {
+	clone := *state
+	if state.world != nil {
+		world := state.world.Clone()
+		clone.world = &world
+	}
+	return clone.TakeAction(action)
+}
+
+// IsTerminal ...
+func (state *gadgetState) IsTerminal() bool {
+	return state.phase == gadgetTerminated || (state.phase == gadgetPlay && state.world.IsTerminal())
+}
+
+// IsChanceNode ...
+func (state *gadgetState) IsChanceNode() bool {
+	return state.phase == gadgetDeal || (state.phase == gadgetRoot && !state.gadget.maxMargin)
+}
+
+// ChanceOutcomes deals an opponent info set, or a world within one, with
+// probability proportional to its reach
+func (state *gadgetState) ChanceOutcomes() ([]Action, []float64) {
+	if state.phase == gadgetRoot {
+		total := 0.0
+		for _, weight := range state.gadget.groupWeights {
+			total += weight
+		}
+		outcomes := state.ValidActions()
+		probs := make([]float64, len(outcomes))
+		for g, weight := range state.gadget.groupWeights {
+			probs[g] = weight / total
+		}
+		return outcomes, probs
+	}
+
+	group := state.gadget.groups[state.group]
+	outcomes := make([]Action, len(group))
+	probs := make([]float64, len(group))
+	for i, w := range group {
+		outcomes[i] = Action(w)
+		probs[i] = state.gadget.weights[w] / state.gadget.groupWeights[state.group]
+	}
+	return outcomes, probs
+}
+
+// GetCurrentAgent ...
+func (state *gadgetState) GetCurrentAgent() int {
+	if state.phase == gadgetPlay {
+		return state.world.GetCurrentAgent()
+	}
+	return state.gadget.opponent
+}
+
+// GetUtility ...
+func (state *gadgetState) GetUtility(playerID int) float64 {
+	alt := state.gadget.altValues[state.group]
+	if playerID%2 != state.gadget.opponent%2 {
+		alt = -alt
+	}
+
+	if state.phase == gadgetTerminated {
+		return alt
+	}
+	if state.gadget.maxMargin {
+		return state.world.GetUtility(playerID) - alt
+	}
+	return state.world.GetUtility(playerID)
+}
+
+// GetInfoSetKey ...
+func (state *gadgetState) GetInfoSetKey() InfoSetKey {
+	switch state.phase {
+	case gadgetRoot:
+		return InfoSetKey("gadget")
+	case gadgetChoice:
+		return InfoSetKey("gadget_") + state.gadget.groupKeys[state.group]
+	}
+	return state.world.GetInfoSetKey()
+}
+
+// unwrap implements wrapper
+func (state *gadgetState) unwrap() State {
+	if state.phase == gadgetPlay {
+		return state.world
+	}
+	return nil
+}
+
+// TricksPlayed implements TrickCounter
+func (state *gadgetState) TricksPlayed() int {
+	if state.phase == gadgetPlay {
+		return state.world.TricksPlayed()
+	}
+	return state.gadget.worlds[0].TricksPlayed()
+}
@@ -0,0 +1,94 @@
+package cfr
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// resolveState is the opening lead of a hand, normalized the way the
+// blueprint sees it. No one else has played, so even an untrained
+// blueprint can weigh its worlds.
+func resolveState() EuchreState {
+	r := rand.New(rand.NewSource(1))
+	for {
+		state := dealHand(r, 5)
+		if len(state.ValidActions()) > 1 {
+			state.Normalize(state.TrumpSuit)
+			return state
+		}
+	}
+}
+
+func TestResolveAgentPlaysAValidAction(t *testing.T) {
+	blueprint := NewStrategy()
+	agent := NewResolveAgent(&blueprint)
+	state := resolveState()
+	if action := agent.Act(&state); !isValid(&state, action) {
+		t.Errorf("Played %v, which isn't valid", action)
+	}
+	if agent.FallbackErr != nil {
+		t.Errorf("Fell back to the blueprint: %v", agent.FallbackErr)
+	}
+}
+
+func TestResolveAgentReportsFallingBack(t *testing.T) {
+	late := pimcState()
+	late.Normalize(late.TrumpSuit)
+	states := map[string]EuchreState{"no worlds": resolveState(), "untrained history": late}
+	for name, state := range states {
+		blueprint := NewStrategy()
+		agent := NewResolveAgent(&blueprint)
+		if name == "no worlds" {
+			agent.NumWorlds = 0
+		}
+		if action := agent.Act(&state); !isValid(&state, action) {
+			t.Errorf("%s: played %v, which isn't valid", name, action)
+		}
+		if agent.FallbackErr == nil {
+			t.Errorf("%s: played the blueprint without reporting it", name)
+		}
+	}
+}
+
+// Terminating at the gadget pays the opponents what the blueprint earns them
+// from the worlds of their info set
+func TestResolveGadgetKeepsBlueprintValue(t *testing.T) {
+	blueprint := NewStrategy()
+	value := DoubleDummyValue{}
+	agent := ResolveAgent{Blueprint: &blueprint, NumWorlds: 8, AltValue: value}
+	state := resolveState()
+	playerID := state.GetCurrentAgent()
+
+	gadget, err := agent.buildGadget(&state)
+	if gadget == nil {
+		t.Fatal(err)
+	}
+	for g, group := range gadget.groups {
+		opponents := func(world EuchreState) [2]CardSet {
+			return [2]CardSet{world.playerHands[gadget.opponent], world.playerHands[(gadget.opponent+2)%4]}
+		}
+		expected := 0.0
+		for _, w := range group {
+			world := gadget.worlds[w]
+			if opponents(world) != opponents(gadget.worlds[group[0]]) {
+				t.Fatalf("Group %d mixes the opponents' hands", g)
+			}
+			expected += gadget.weights[w] * value.Value(&world, gadget.opponent)
+		}
+		expected /= gadget.groupWeights[g]
+
+		root := &gadgetState{gadget: gadget}
+		root.TakeAction(Action(g))
+		root.TakeAction(GADGET_TERMINATE)
+		if !root.IsTerminal() {
+			t.Fatal("Terminating should end the gadget")
+		}
+		if got := root.GetUtility(gadget.opponent); math.Abs(got-expected) > 1e-9 {
+			t.Errorf("Group %d pays the opponents %v, the blueprint earns them %v", g, got, expected)
+		}
+		if got := root.GetUtility(playerID); math.Abs(got+expected) > 1e-9 {
+			t.Errorf("Group %d pays the agent %v, expected %v", g, got, -expected)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package cfr
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+)
+
+// numShards is the number of independently locked pieces of a Strategy's
+// info set store. Workers only contend when their keys hash to the same
+// shard.
+const numShards = 256
+
+// infoSetShard guards one piece of the info set store
+type infoSetShard struct {
+	sync.Mutex
+	infoSets map[PackedKey]*InfoSet
+}
+
+func newShards() []*infoSetShard {
+	shards := make([]*infoSetShard, numShards)
+	for i := range shards {
+		shards[i] = &infoSetShard{infoSets: make(map[PackedKey]*InfoSet)}
+	}
+	return shards
+}
+
+// shard returns the shard responsible for key, mixing both words so packed
+// keys that differ in any field spread across the shards
+func (strat *Strategy) shard(key PackedKey) *infoSetShard {
+	hash := (key[0] ^ key[1]*0x9e3779b97f4a7c15) * 0xff51afd7ed558ccd
+	return strat.shards[hash>>56%numShards]
+}
+
+// Len returns the number of info sets in the strategy
+func (strat *Strategy) Len() int {
+	total := 0
+	for _, shard := range strat.shards {
+		shard.Lock()
+		total += len(shard.infoSets)
+		shard.Unlock()
+	}
+	return total
+}
+
+// averageStrategy returns the average strategy stored for key
+func (strat *Strategy) averageStrategy(key PackedKey) (map[Action]float64, bool) {
+	shard := strat.shard(key)
+	shard.Lock()
+	defer shard.Unlock()
+
+	info, exists := shard.infoSets[key]
+	if !exists {
+		return nil, false
+	}
+	return info.getAverageStrategy(), true
+}
+
+// stateStrategy returns the regret matching strategy stored for key
+func (strat *Strategy) stateStrategy(key PackedKey) (map[Action]float64, bool) {
+	shard := strat.shard(key)
+	shard.Lock()
+	defer shard.Unlock()
+
+	info, exists := shard.infoSets[key]
+	if !exists {
+		return nil, false
+	}
+	return info.getStateStrategy(), true
+}
+
+// STRATEGY_FILE_VERSION is written at the head of every strategy file.
+// Bump it whenever an InfoSet or the packing of any abstraction's keys
+// changes, so files trained under the old keys are refused instead of
+// loading info sets that are never looked up. Version 1 held map based
+// info sets.
+const STRATEGY_FILE_VERSION = 2
+
+// strategyFile is what Save writes
+type strategyFile struct {
+	Version  int
+	InfoSets map[PackedKey]*InfoSet
+}
+
+// Save writes every info set as a gob encoded strategyFile. It holds every
+// shard lock so it can run while training.
+func (strat *Strategy) Save(w io.Writer) error {
+	infoSets := make(map[PackedKey]*InfoSet)
+	for _, shard := range strat.shards {
+		shard.Lock()
+		defer shard.Unlock()
+		for key, info := range shard.infoSets {
+			infoSets[key] = info
+		}
+	}
+
+	return gob.NewEncoder(w).Encode(strategyFile{Version: STRATEGY_FILE_VERSION, InfoSets: infoSets})
+}
+
+// legacyInfoSet is the map based InfoSet that older strategy files hold
+type legacyInfoSet struct {
+	CumulativeStrategySum map[Action]float64
+	CumulativeRegret      map[Action]float64
+	CurrentStrategy       map[Action]float64
+}
+
+// legacyStrategyFile is what Save wrote in version 1
+type legacyStrategyFile struct {
+	Version  int
+	InfoSets map[PackedKey]*legacyInfoSet
+}
+
+// upgrade converts a legacy info set, with its actions in ascending order
+func (legacy *legacyInfoSet) upgrade() *InfoSet {
+	actions := make([]Action, 0, len(legacy.CumulativeRegret))
+	for action := range legacy.CumulativeRegret {
+		actions = append(actions, action)
+	}
+	sort.Slice(actions, func(j, k int) bool {
+		return actions[j] < actions[k]
+	})
+
+	info := makeInfoSet(actions)
+	for i, action := range actions {
+		info.CumulativeStrategySum[i] = legacy.CumulativeStrategySum[action]
+		info.CumulativeRegret[i] = legacy.CumulativeRegret[action]
+		info.CurrentStrategy[i] = legacy.CurrentStrategy[action]
+	}
+	return info
+}
+
+// Load reads info sets written by Save, replacing any with the same key.
+// Version 1 files and files from before versioning hold map based info
+// sets, which are upgraded as they load. Other versions are refused. The
+// unversioned files hold a bare map keyed by PackedKey or, the oldest, by
+// InfoSetKey. Those keys are hashed, which suits Kuhn and Leduc
+// strategies, but Euchre strategies saved that way can't be reused:
+// EuchreAbstraction packs its keys, so none of their info sets is ever
+// found. Retrain them instead.
+func (strat *Strategy) Load(r io.Reader) error {
+	// The format is only known once decoding fails, so every attempt needs
+	// the whole file
+	var data bytes.Buffer
+	if _, err := data.ReadFrom(r); err != nil {
+		return err
+	}
+	decode := func(value interface{}) error {
+		return gob.NewDecoder(bytes.NewReader(data.Bytes())).Decode(value)
+	}
+
+	// Gob skips the fields a value doesn't have, so the version can be read
+	// on its own before the info sets
+	var infoSets map[PackedKey]*InfoSet
+	var header struct{ Version int }
+	err := decode(&header)
+	switch {
+	case err == nil && header.Version == STRATEGY_FILE_VERSION:
+		var file strategyFile
+		if err := decode(&file); err != nil {
+			return err
+		}
+		infoSets = file.InfoSets
+	case err == nil && header.Version == 1:
+		var file legacyStrategyFile
+		if err := decode(&file); err != nil {
+			return err
+		}
+		infoSets = make(map[PackedKey]*InfoSet, len(file.InfoSets))
+		for key, legacy := range file.InfoSets {
+			infoSets[key] = legacy.upgrade()
+		}
+	case err == nil:
+		return fmt.Errorf("Strategy file is version %d, expected version %d", header.Version, STRATEGY_FILE_VERSION)
+	default:
+		packedSets := make(map[PackedKey]*legacyInfoSet)
+		legacySets := make(map[InfoSetKey]*legacyInfoSet)
+		if decode(&packedSets) == nil {
+			infoSets = make(map[PackedKey]*InfoSet, len(packedSets))
+			for key, legacy := range packedSets {
+				infoSets[key] = legacy.upgrade()
+			}
+		} else if decode(&legacySets) == nil {
+			infoSets = make(map[PackedKey]*InfoSet, len(legacySets))
+			for key, legacy := range legacySets {
+				infoSets[hashKey(key)] = legacy.upgrade()
+			}
+		} else {
+			return err
+		}
+	}
+
+	for key, info := range infoSets {
+		shard := strat.shard(key)
+		shard.Lock()
+		shard.infoSets[key] = info
+		shard.Unlock()
+	}
+	return nil
+}
+
+// LoadStrategy reads a strategy file written by Save, such as the
+// strategy.gob written by training
+func LoadStrategy(path string) (*Strategy, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	strat := NewStrategy()
+	if err := strat.Load(file); err != nil {
+		return nil, err
+	}
+	return &strat, nil
+}
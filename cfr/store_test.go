@@ -0,0 +1,127 @@
+package cfr
+
+import (
+	"bytes"
+	"encoding/gob"
+	"reflect"
+	"testing"
+)
+
+// storedInfoSets copies every info set out of the shards
+func storedInfoSets(strat *Strategy) map[PackedKey]*InfoSet {
+	infoSets := make(map[PackedKey]*InfoSet)
+	for _, shard := range strat.shards {
+		for key, info := range shard.infoSets {
+			infoSets[key] = info
+		}
+	}
+	return infoSets
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	strat := NewStrategy()
+	trainCFR(&strat, kuhnRoot, 50)
+
+	var file bytes.Buffer
+	if err := strat.Save(&file); err != nil {
+		t.Fatal(err)
+	}
+	loaded := NewStrategy()
+	if err := loaded.Load(&file); err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded.Len() != strat.Len() || !reflect.DeepEqual(storedInfoSets(&loaded), storedInfoSets(&strat)) {
+		t.Fatal("Loading a saved strategy gave different info sets")
+	}
+}
+
+func TestLoadRefusesOtherVersions(t *testing.T) {
+	strat := NewStrategy()
+	trainCFR(&strat, kuhnRoot, 10)
+
+	var file bytes.Buffer
+	newer := strategyFile{Version: STRATEGY_FILE_VERSION + 1, InfoSets: storedInfoSets(&strat)}
+	if err := gob.NewEncoder(&file).Encode(newer); err != nil {
+		t.Fatal(err)
+	}
+	loaded := NewStrategy()
+	if err := loaded.Load(&file); err == nil {
+		t.Fatal("A file of another version loaded")
+	}
+	if loaded.Len() != 0 {
+		t.Fatalf("A refused file still loaded %d info sets", loaded.Len())
+	}
+}
+
+// legacyInfoSets converts every info set of a Kuhn strategy to the map
+// based info sets older files held, keyed both ways those files were
+func legacyInfoSets(strat *Strategy) (map[PackedKey]*legacyInfoSet, map[InfoSetKey]*legacyInfoSet) {
+	byPacked := make(map[PackedKey]*legacyInfoSet)
+	byString := make(map[InfoSetKey]*legacyInfoSet)
+	stored := storedInfoSets(strat)
+	var visit func(state State)
+	visit = func(state State) {
+		if state.IsTerminal() {
+			return
+		}
+		if state.IsChanceNode() {
+			outcomes, _ := state.ChanceOutcomes()
+			for _, outcome := range outcomes {
+				visit(state.TakeActionCopy(outcome))
+			}
+			return
+		}
+
+		info := stored[strat.infoSetKey(state)]
+		legacy := legacyInfoSet{
+			CumulativeStrategySum: make(map[Action]float64),
+			CumulativeRegret:      make(map[Action]float64),
+			CurrentStrategy:       make(map[Action]float64),
+		}
+		for i, action := range info.Actions {
+			legacy.CumulativeStrategySum[action] = info.CumulativeStrategySum[i]
+			legacy.CumulativeRegret[action] = info.CumulativeRegret[i]
+			legacy.CurrentStrategy[action] = info.CurrentStrategy[i]
+		}
+		byPacked[strat.infoSetKey(state)] = &legacy
+		byString[state.GetInfoSetKey()] = &legacy
+		for _, action := range state.ValidActions() {
+			visit(state.TakeActionCopy(action))
+		}
+	}
+	visit(kuhnRoot())
+	return byPacked, byString
+}
+
+func TestLoadUpgradesLegacyFiles(t *testing.T) {
+	strat := NewStrategy()
+	trainCFR(&strat, kuhnRoot, 50)
+	byPacked, byString := legacyInfoSets(&strat)
+
+	files := map[string]interface{}{
+		"version 1":                legacyStrategyFile{Version: 1, InfoSets: byPacked},
+		"unversioned packed keyed": byPacked,
+		"unversioned string keyed": byString,
+	}
+	for name, legacyFile := range files {
+		var file bytes.Buffer
+		if err := gob.NewEncoder(&file).Encode(legacyFile); err != nil {
+			t.Fatal(err)
+		}
+		loaded := NewStrategy()
+		if err := loaded.Load(&file); err != nil {
+			t.Fatalf("Loading a %s file: %v", name, err)
+		}
+		if !reflect.DeepEqual(storedInfoSets(&loaded), storedInfoSets(&strat)) {
+			t.Errorf("A %s file loaded different info sets", name)
+		}
+	}
+}
+
+func TestLoadRejectsGarbage(t *testing.T) {
+	strat := NewStrategy()
+	if err := strat.Load(bytes.NewReader([]byte("not a strategy"))); err == nil {
+		t.Fatal("Garbage loaded as a strategy")
+	}
+}
@@ -0,0 +1,26 @@
+package cfr
+
+// StrategyAgent plays the average strategy of a trained Strategy without
+// searching at decision time
+type StrategyAgent struct {
+	Strat *Strategy
+}
+
+func (agent StrategyAgent) EndGame() {
+
+}
+
+// Act samples an action from the strategy's policy
+func (agent StrategyAgent) Act(state State) Action {
+	return sampleAction(agent.Strat.Policy(state))
+}
+
+// Policy implements PolicyModel
+func (agent StrategyAgent) Policy(state State) map[Action]float64 {
+	return agent.Strat.Policy(state)
+}
+
+// storedPolicy implements storedPolicyModel
+func (agent StrategyAgent) storedPolicy(state State) (map[Action]float64, bool) {
+	return agent.Strat.storedPolicy(state)
+}
@@ -0,0 +1,148 @@
+package cfr
+
+// Once trump is fixed, the two suits of the other colour play identically.
+// Neither holds a bower, so every play in one has a mirror in the other.
+// The suit of trump's colour gives its jack to trump and stands alone. An
+// info set and its mirror with those two suits swapped are strategically
+// the same, so they can share a key once the suits are put in a canonical
+// order.
+
+// otherColourSuits returns the two suits of the other colour from trump,
+// lowest first
+func otherColourSuits(trumpSuit Suit) (Suit, Suit) {
+	suits := make([]Suit, 0, 2)
+	for s := DIAMONDS; s <= CLUBS; s += 10 {
+		if s != trumpSuit && s != trumpSuit.complement() {
+			suits = append(suits, s)
+		}
+	}
+	return suits[0], suits[1]
+}
+
+// suitSignature encodes everything the current agent knows about a plain
+// suit under keys: where each of its cards has been seen and which of the
+// other seats are void in it. Two suits with equal signatures can be
+// swapped without changing the info set.
+func (state *EuchreState) suitSignature(suit Suit, keys InfoSetKeyMode) uint64 {
+	// Per value: 0 unseen, 1 held, 2 upcard, then played
+	var codes [ACE + 1]uint64
+	for _, card := range (state.playerHands[state.currentAgent] & SuitMask(suit)).Cards() {
+		codes[card.getValue()] = 1
+	}
+	if upcard := state.upcard; upcard.getSuit() == suit {
+		codes[upcard.getValue()] = 2
+	}
+
+	// Compact keys only remember the cards of the current trick in order
+	tableStart := state.numPlayed - state.numPlayed%4
+	for i, card := range state.playedCards() {
+		if card.getSuit() != suit {
+			continue
+		}
+		switch {
+		case keys == PERFECT_RECALL_KEYS:
+			codes[card.getValue()] = uint64(3 + i)
+		case i >= tableStart:
+			codes[card.getValue()] = uint64(4 + i - tableStart)
+		default:
+			codes[card.getValue()] = 3
+		}
+	}
+
+	var signature uint64
+	for value := ACE; value >= NINE; value-- {
+		signature = signature<<8 | codes[value]
+	}
+	for i := 1; i < 4; i++ {
+		signature <<= 1
+		if state.isVoid((state.currentAgent+i)%4, suit) {
+			signature |= 1
+		}
+	}
+	return signature
+}
+
+// suitRelabeling is how an EuchreAbstraction renames the suits of a state:
+// trump to spades, then the other colour suits swapped into canonical order
+type suitRelabeling struct {
+	// Trump suit to normalize to spades, 0 to leave the suits as they are
+	normalize Suit
+	// Normalized suits to swap, zero when they are already canonical
+	swap [2]Suit
+}
+
+// relabeling works out the renaming for state. The canonical order puts
+// the suit with the larger signature under the lower label.
+func (abstraction EuchreAbstraction) relabeling(state *EuchreState) suitRelabeling {
+	var relabeling suitRelabeling
+	trumpSuit := state.TrumpSuit
+	if abstraction.NormalizeTrump && trumpSuit != SPADES {
+		relabeling.normalize = trumpSuit
+	}
+	if !abstraction.CanonicalSuits {
+		return relabeling
+	}
+
+	a, b := otherColourSuits(trumpSuit)
+	labelA, labelB := a, b
+	if relabeling.normalize != 0 {
+		labelA, labelB = a.normalizeSuit(trumpSuit), b.normalizeSuit(trumpSuit)
+	}
+	if labelA > labelB {
+		a, b = b, a
+		labelA, labelB = labelB, labelA
+	}
+	if state.suitSignature(a, state.KeyMode) < state.suitSignature(b, state.KeyMode) {
+		relabeling.swap = [2]Suit{labelA, labelB}
+	}
+	return relabeling
+}
+
+// identity reports whether the relabeling leaves every suit alone
+func (relabeling suitRelabeling) identity() bool {
+	return relabeling.normalize == 0 && relabeling.swap[0] == 0
+}
+
+// apply renames the suits of state
+func (relabeling suitRelabeling) apply(state *EuchreState) {
+	if relabeling.normalize != 0 {
+		state.Normalize(relabeling.normalize)
+	}
+	if relabeling.swap[0] != 0 {
+		state.swapSuits(relabeling.swap[0], relabeling.swap[1])
+	}
+}
+
+// concreteCard maps a card named under the relabeling back to the state's
+// own suits
+func (relabeling suitRelabeling) concreteCard(card Card) Card {
+	switch card.getSuit() {
+	case relabeling.swap[0]:
+		card = makeCard(relabeling.swap[1], card.getValue())
+	case relabeling.swap[1]:
+		card = makeCard(relabeling.swap[0], card.getValue())
+	}
+	if relabeling.normalize != 0 {
+		card.normalizeSuit(relabeling.normalize)
+	}
+	return card
+}
+
+// abstractSuit names one of the state's suits under the relabeling
+func (relabeling suitRelabeling) abstractSuit(suit Suit) Suit {
+	if relabeling.normalize != 0 {
+		suit = suit.normalizeSuit(relabeling.normalize)
+	}
+	switch suit {
+	case relabeling.swap[0]:
+		return relabeling.swap[1]
+	case relabeling.swap[1]:
+		return relabeling.swap[0]
+	}
+	return suit
+}
+
+// abstractCard names one of the state's cards under the relabeling
+func (relabeling suitRelabeling) abstractCard(card Card) Card {
+	return makeCard(relabeling.abstractSuit(card.getSuit()), card.getValue())
+}
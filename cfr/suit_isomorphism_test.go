@@ -0,0 +1,74 @@
+package cfr
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// isomorphismStates are random positions, many of them mid-trick
+func isomorphismStates(r *rand.Rand) []EuchreState {
+	states := make([]EuchreState, 0)
+	for len(states) < 200 {
+		if state := playedInto(r, r.Intn(20)); !state.IsTerminal() {
+			states = append(states, state)
+		}
+	}
+	return states
+}
+
+func TestSwappedSuitsShareAKey(t *testing.T) {
+	abstraction := NewEuchreAbstraction()
+	for _, mode := range []InfoSetKeyMode{COMPACT_KEYS, PERFECT_RECALL_KEYS} {
+		for _, state := range isomorphismStates(rand.New(rand.NewSource(1))) {
+			state.KeyMode = mode
+			mirror := state.Clone()
+			a, b := otherColourSuits(state.TrumpSuit)
+			mirror.swapSuits(a, b)
+
+			key := abstraction.InfoSetKey(&state)
+			if mirrorKey := abstraction.InfoSetKey(&mirror); mirrorKey != key {
+				t.Fatalf("Swapping %v and %v changed key %v to %v", a, b, key, mirrorKey)
+			}
+
+			// The mirror's actions map back onto the mirrored cards
+			for _, action := range abstraction.Actions(&mirror) {
+				if concrete := abstraction.ConcreteAction(&mirror, action); !isValid(&mirror, concrete) {
+					t.Fatalf("Action %v maps to %v, which the mirror can't play", action, concrete)
+				}
+			}
+		}
+	}
+}
+
+func TestTrumpAndLeadAreNeverSwapped(t *testing.T) {
+	abstraction := NewEuchreAbstraction()
+	midTrick := 0
+	for _, state := range isomorphismStates(rand.New(rand.NewSource(2))) {
+		relabeling := abstraction.relabeling(&state)
+		for _, suit := range relabeling.swap {
+			if suit == SPADES || suit == CLUBS {
+				t.Fatalf("The swap %v touches trump's colour", relabeling.swap)
+			}
+		}
+
+		relabeled := state.Clone()
+		relabeling.apply(&relabeled)
+		if relabeled.TrumpSuit != SPADES {
+			t.Fatalf("Trump became %v", relabeled.TrumpSuit)
+		}
+		if len(state.trick()) == 0 {
+			continue
+		}
+		midTrick++
+		led, relabeledLed := state.trick()[0], relabeled.trick()[0]
+		if lead := relabeledLed.effectiveSuit(relabeled.TrumpSuit); lead != relabeled.leadSuit {
+			t.Fatalf("The led card is %v but the lead suit became %v", lead, relabeled.leadSuit)
+		}
+		if relabeling.concreteCard(relabeledLed) != led {
+			t.Fatalf("The led card %v doesn't map back to %v", relabeledLed, led)
+		}
+	}
+	if midTrick == 0 {
+		t.Error("No position was mid-trick, so the lead went untested")
+	}
+}
@@ -0,0 +1,43 @@
+package cfr
+
+import "sync"
+
+// Train runs iterations of CFR on a pool of numWorkers goroutines that all
+// update the same Strategy. Each iteration traverses the game once for
+// every player with PruningCFR. A traversal plays forced moves on the state
+// it is given, so every traversal gets a fresh root from newRoot, which must
+// be safe to call concurrently. The average utility summed over players is
+// returned.
+func (strat *Strategy) Train(newRoot func() State, numPlayers int, iterations int, numWorkers int) float64 {
+	iterationQueue := make(chan int)
+	workerUtility := make([]float64, numWorkers)
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < numWorkers; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for iteration := range iterationQueue {
+				for playerID := 0; playerID < numPlayers; playerID++ {
+					probs := make([]float64, numPlayers)
+					for p := range probs {
+						probs[p] = 1.0
+					}
+					workerUtility[worker] += strat.PruningCFR(playerID, newRoot(), probs, iteration)
+				}
+			}
+		}(worker)
+	}
+
+	for iteration := 0; iteration < iterations; iteration++ {
+		iterationQueue <- iteration
+	}
+	close(iterationQueue)
+	wg.Wait()
+
+	utility := 0.0
+	for _, workerUtil := range workerUtility {
+		utility += workerUtil
+	}
+	return utility / float64(iterations)
+}
@@ -0,0 +1,40 @@
+package cfr
+
+import (
+	"math"
+	"sync/atomic"
+	"testing"
+)
+
+func TestTrainWorkersMatchOneWorker(t *testing.T) {
+	single, pooled := NewStrategy(), NewStrategy()
+	single.Train(kuhnRoot, 2, 20000, 1)
+	pooled.Train(kuhnRoot, 2, 20000, 4)
+
+	singleReport := single.Exploitability(kuhnRoot(), 2)
+	pooledReport := pooled.Exploitability(kuhnRoot(), 2)
+	t.Logf("NashConv %f with one worker, %f with four", singleReport.NashConv, pooledReport.NashConv)
+
+	if pooled.Len() != single.Len() {
+		t.Errorf("Four workers trained %d info sets, one worker %d", pooled.Len(), single.Len())
+	}
+	if singleReport.NashConv > 0.05 || pooledReport.NashConv > 0.05 {
+		t.Errorf("NashConv should be near 0, got %f with one worker and %f with four", singleReport.NashConv, pooledReport.NashConv)
+	}
+	if diff := pooledReport.PolicyValues[0] - singleReport.PolicyValues[0]; math.Abs(diff) > 0.02 {
+		t.Errorf("Four workers value the game at %f, one worker at %f", pooledReport.PolicyValues[0], singleReport.PolicyValues[0])
+	}
+}
+
+func TestTrainTakesARootPerTraversal(t *testing.T) {
+	var roots int64
+	newRoot := func() State {
+		atomic.AddInt64(&roots, 1)
+		return kuhnRoot()
+	}
+	strat := NewStrategy()
+	strat.Train(newRoot, 2, 100, 4)
+	if roots != 200 {
+		t.Errorf("100 iterations of 2 players took %d roots", roots)
+	}
+}
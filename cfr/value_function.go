@@ -0,0 +1,72 @@
+package cfr
+
+import "math/rand"
+
+// ValueFunction estimates the utility of a position for playerID without
+// searching it. Depth-limited searches use it to score their leaves.
+type ValueFunction interface {
+	Value(state State, playerID int) float64
+}
+
+// TrickCounter is implemented by trick taking games so searches can be
+// limited to a number of tricks
+type TrickCounter interface {
+	TricksPlayed() int
+}
+
+// RolloutValue averages the utility of NumRollouts playouts of the
+// position. Every seat plays the average strategy of Strat, or uniformly
+// random actions when Strat is nil.
+type RolloutValue struct {
+	Strat       *Strategy
+	NumRollouts int
+}
+
+// Value implements ValueFunction
+func (value RolloutValue) Value(state State, playerID int) float64 {
+	if state.IsTerminal() {
+		return state.GetUtility(playerID)
+	}
+
+	total := 0.0
+	for r := 0; r < value.NumRollouts; r++ {
+		total += playOut(state.TakeActionCopy(rolloutAction(state, value.Strat)), value.Strat, playerID)
+	}
+	return total / float64(value.NumRollouts)
+}
+
+// DoubleDummyValue scores the position by its minimax value with every
+// card visible. Depth-limited searches already run on sampled worlds, so
+// the concrete position is solved as it is and the values average out over
+// the worlds the search draws.
+type DoubleDummyValue struct {
+	// Endgame, if set, answers late positions without searching them
+	Endgame *EndgameTable
+}
+
+// Value implements ValueFunction
+func (value DoubleDummyValue) Value(state State, playerID int) float64 {
+	solved, _ := minimax(state, playerID, value.Endgame)
+	return solved
+}
+
+// playOut plays state to the end in place, as RolloutValue does, and
+// returns the utility for playerID
+func playOut(state State, strat *Strategy, playerID int) float64 {
+	for !state.IsTerminal() {
+		state = state.TakeAction(rolloutAction(state, strat))
+	}
+	return state.GetUtility(playerID)
+}
+
+// rolloutAction samples the next action of a playout
+func rolloutAction(state State, strat *Strategy) Action {
+	if state.IsChanceNode() {
+		return SampleChanceOutcome(state)
+	}
+	if strat == nil {
+		actions := state.ValidActions()
+		return actions[rand.Intn(len(actions))]
+	}
+	return sampleAction(strat.Policy(state))
+}
@@ -0,0 +1,30 @@
+package cfr
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// The double dummy leaf value of a deal is its exact value for every seat
+func TestDoubleDummyValueIsExact(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for d := 0; d < 100; d++ {
+		state := dealHand(r, 3)
+		deal := state.Deal()
+		solver, err := NewDoubleDummySolver(deal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		points := float64(solver.Solve().MakerPoints)
+
+		for playerID := 0; playerID < 4; playerID++ {
+			expected := points
+			if playerID%2 != deal.Maker%2 {
+				expected = -points
+			}
+			if value := (DoubleDummyValue{}).Value(&state, playerID); value != expected {
+				t.Fatalf("Deal %v: seat %d has leaf value %v, exact value %v", deal, playerID, value, expected)
+			}
+		}
+	}
+}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"encoding/gob"
 	"flag"
 	"fmt"
 	"log"
@@ -16,6 +15,13 @@ import (
 func main() {
 	var cpuprofile = flag.String("cpuprofile", "", "write cpu profile to `file`")
 	var memprofile = flag.String("memprofile", "", "write memory profile to `file`")
+	var lbrGames = flag.Int("lbrgames", 0, "evaluate the strategy with `n` local best response games")
+	var pruneInterval = flag.Int("pruneinterval", 10, "traverse pruned actions every `n` iterations, 0 disables pruning")
+	var numWorkers = flag.Int("workers", runtime.NumCPU(), "number of goroutines running CFR iterations")
+	var maxIter = flag.Int("iterations", 10, "number of CFR iterations to run")
+	var pruneThreshold = flag.Float64("prunethreshold", -5.0, "prune actions with cumulative regret below `threshold`")
+	var perfectRecall = flag.Bool("perfectrecall", false, "key info sets by every card played in order instead of the compact abstraction")
+	var endgameCards = flag.Int("endgame", 0, "score positions with at most `n` cards per hand from an endgame table, 0 disables it")
 
 	flag.Parse()
 	if *cpuprofile != "" {
@@ -33,12 +39,13 @@ func main() {
 	/* START CODE */
 
 	strat := cfr.NewStrategy()
+	strat.Pruning = cfr.RegretPruning{Threshold: *pruneThreshold, RevisitInterval: *pruneInterval}
+	strat.Abstraction = cfr.NewEuchreAbstraction()
 
 	// Load strategy file
 	dataFile, err := os.Open("strategy.gob")
 	if err == nil {
-		stratDecoder := gob.NewDecoder(dataFile)
-		err = stratDecoder.Decode(&strat.InfoSetMap)
+		err = strat.Load(dataFile)
 		dataFile.Close()
 
 		if err != nil {
@@ -47,40 +54,82 @@ func main() {
 		}
 	}
 
+	// Load or generate the endgame table
+	if *endgameCards > 0 {
+		strat.Endgame = cfr.NewEndgameTable(*endgameCards)
+		tableFile, err := os.Open("endgame.gob")
+		if err == nil {
+			err = strat.Endgame.Load(tableFile)
+			tableFile.Close()
+
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(0)
+			}
+		} else {
+			for cards := 1; cards <= *endgameCards && cards <= 2; cards++ {
+				if _, err := strat.Endgame.Generate(cards); err != nil {
+					fmt.Println(err)
+					os.Exit(0)
+				}
+			}
+		}
+	}
+
 	begin := time.Now().UnixNano()
-	util := 0.0
-	maxIter := 10
-	for iter := 0; iter < maxIter; iter++ {
+	newRoot := func() cfr.State {
 		state := cfr.NewEuchreState()
-		//sampledState, _ := state.SampleInfoSet()
+		// Normalizing the root saves the abstraction relabeling every state
 		trump := state.TrumpSuit
 		state.Normalize(trump)
-		for playerId := 0; playerId < 4; playerId++ {
-			probs := make([]float64, 4)
-			for p := 0; p < 4; p++ {
-				probs[p] = 1.0
-			}
-
-			util += strat.CFR(playerId, &state, probs)
-			fmt.Printf("There are %d info sets in the map.\n", len(strat.InfoSetMap))
+		if *perfectRecall {
+			state.KeyMode = cfr.PERFECT_RECALL_KEYS
 		}
+		return &state
 	}
+	util := strat.Train(newRoot, 4, *maxIter, *numWorkers)
 	end := time.Now().UnixNano()
+	fmt.Printf("There are %d info sets in the map.\n", strat.Len())
 
 	dataFile, err = os.Create("strategy.gob")
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(0)
 	}
-	stratEncoder := gob.NewEncoder(dataFile)
-	err = stratEncoder.Encode(strat.InfoSetMap)
+	err = strat.Save(dataFile)
+	dataFile.Close()
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(0)
 	}
 
-	fmt.Printf("Mean time %f seconds per iteration\n", (float64(end-begin)/float64(1e9))/float64(maxIter*4))
-	fmt.Printf("Average utility %f\n", util/float64(maxIter))
+	if strat.Endgame != nil {
+		tableFile, err := os.Create("endgame.gob")
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(0)
+		}
+		err = strat.Endgame.Save(tableFile)
+		tableFile.Close()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(0)
+		}
+		fmt.Printf("There are %d positions in the endgame table.\n", strat.Endgame.Len())
+	}
+
+	fmt.Printf("Mean time %f seconds per iteration\n", (float64(end-begin)/float64(1e9))/float64(*maxIter*4))
+	fmt.Printf("Average utility %f\n", util)
+
+	if *lbrGames > 0 {
+		lbr := cfr.LocalBestResponse{Strat: &strat, NumWorlds: 10, NumRollouts: 1}
+		if *perfectRecall {
+			lbr.KeyMode = cfr.PERFECT_RECALL_KEYS
+		}
+		report := lbr.Evaluate(*lbrGames)
+		fmt.Printf("LBR gain %f +/- %f over %d games\n", report.Gain, report.GainCI, report.Games)
+		fmt.Printf("\tLBR utility %f +/- %f, strategy utility %f +/- %f\n", report.Value, report.ValueCI, report.Baseline, report.BaselineCI)
+	}
 
 	/* END CODE */
 
@@ -1,14 +1,44 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"log"
+	"time"
 
 	"github.com/drewhayward/trick-taking-ai/cfr"
 )
 
+// newOpponent builds the agent that plays against CFR
+func newOpponent(name string, numWorlds int, budget time.Duration) cfr.Agent {
+	switch name {
+	case "random":
+		return cfr.RandomAgent{}
+	case "pimc":
+		return cfr.PIMCAgent{NumWorlds: numWorlds}
+	case "ismcts":
+		return cfr.ISMCTSAgent{TimeBudget: budget, Exploration: 1.4}
+	case "resolve":
+		blueprint, err := cfr.LoadStrategy("strategy.gob")
+		if err != nil {
+			log.Fatal("could not load the blueprint: ", err)
+		}
+		blueprint.Abstraction = cfr.NewEuchreAbstraction()
+		return cfr.NewResolveAgent(blueprint)
+	}
+	log.Fatalf("unknown opponent %q", name)
+	return nil
+}
+
 func main() {
+	var opponent = flag.String("opponent", "random", "agent playing against CFR: random, pimc, ismcts or resolve")
+	var numWorlds = flag.Int("worlds", 10, "worlds sampled per decision by the pimc opponent")
+	var budget = flag.Duration("budget", 100*time.Millisecond, "search time per decision for the ismcts opponent")
+	flag.Parse()
+
 	state := cfr.NewEuchreState()
 	strat := cfr.NewStrategy()
+	strat.Abstraction = cfr.NewEuchreAbstraction()
 	game := cfr.Game{
 		GameState: &state,
 		Agents:    make([]cfr.Agent, 4),
@@ -27,9 +57,9 @@ func main() {
 	for _, numIter := range iters {
 		evenWins := 0
 		oddWins := 0
-		game.Agents[0] = cfr.RandomAgent{}
+		game.Agents[0] = newOpponent(*opponent, *numWorlds, *budget)
 		game.Agents[1] = &cfr.CFRAgent{Strat: &strat, NumIterations: numIter}
-		game.Agents[2] = cfr.RandomAgent{}
+		game.Agents[2] = newOpponent(*opponent, *numWorlds, *budget)
 		game.Agents[3] = &cfr.CFRAgent{Strat: &strat, NumIterations: numIter}
 		for i := 0; i < 100; i++ {
 			state = cfr.NewEuchreState()
@@ -46,7 +76,7 @@ func main() {
 				fmt.Printf("Completed game %d\n", i)
 			}
 		}
-		fmt.Printf("Random Score: %d, CFR score %d, Num samples %d\n", evenWins, oddWins, numIter)
+		fmt.Printf("%s Score: %d, CFR score %d, Num samples %d\n", *opponent, evenWins, oddWins, numIter)
 	}
 
 }
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"encoding/gob"
 	"fmt"
 	"os"
 	"time"
@@ -11,12 +10,12 @@ import (
 
 func main() {
 	strat := cfr.NewStrategy()
+	strat.Abstraction = cfr.NewEuchreAbstraction()
 
 	// Load strategy file
 	dataFile, err := os.Open("strategy.gob")
 	if err == nil {
-		stratDecoder := gob.NewDecoder(dataFile)
-		err = stratDecoder.Decode(&strat.InfoSetMap)
+		err = strat.Load(dataFile)
 		dataFile.Close()
 
 		if err != nil {
@@ -40,7 +39,7 @@ func main() {
 			}
 
 			util += strat.CFR(playerId, &state, probs)
-			fmt.Printf("There are %d info sets in the map.\n", len(strat.InfoSetMap))
+			fmt.Printf("There are %d info sets in the map.\n", strat.Len())
 		}
 	}
 	end := time.Now().UnixNano()
@@ -50,8 +49,7 @@ func main() {
 		fmt.Println(err)
 		os.Exit(0)
 	}
-	stratEncoder := gob.NewEncoder(dataFile)
-	err = stratEncoder.Encode(strat.InfoSetMap)
+	err = strat.Save(dataFile)
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(0)